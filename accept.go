@@ -0,0 +1,104 @@
+package chi
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// MediaRange is a media range of an Accept header, such as "text/*;q=0.5",
+// or a media type offered for a response.
+type MediaRange struct {
+	// Type and Subtype are lowercase, and "*" for a wildcard.
+	Type, Subtype string
+
+	// Params are the parameters of the media range, without its q-value.
+	Params map[string]string
+
+	// Q is the q-value of the media range, 1 if it has none.
+	Q float64
+}
+
+// ParseMediaRange parses a media range or type. A bare "*", as sent by some
+// clients, is parsed as "*/*". It returns false if `s` is invalid or has a
+// q-value out of [0, 1].
+func ParseMediaRange(s string) (MediaRange, bool) {
+	mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(s))
+	if err != nil {
+		return MediaRange{}, false
+	}
+	if mediaType == "*" {
+		mediaType = "*/*"
+	}
+	i := strings.IndexByte(mediaType, '/')
+	if i <= 0 || i == len(mediaType)-1 {
+		return MediaRange{}, false
+	}
+
+	mr := MediaRange{Type: mediaType[:i], Subtype: mediaType[i+1:], Params: params, Q: 1}
+	if mr.Type == "*" && mr.Subtype != "*" {
+		return MediaRange{}, false
+	}
+	if q, ok := params["q"]; ok {
+		f, err := strconv.ParseFloat(q, 64)
+		if err != nil || f < 0 || f > 1 {
+			return MediaRange{}, false
+		}
+		mr.Q = f
+		delete(params, "q")
+	}
+	return mr, true
+}
+
+// ParseAccept parses the media ranges of an Accept header, skipping the
+// invalid ones.
+func ParseAccept(accept string) []MediaRange {
+	var ranges []MediaRange
+	for _, s := range strings.Split(accept, ",") {
+		if mr, ok := ParseMediaRange(s); ok {
+			ranges = append(ranges, mr)
+		}
+	}
+	return ranges
+}
+
+// Specificity returns how specifically the media range matches the media
+// type `mt`, or -1 if it doesn't match. A range matches if its type and
+// subtype are the ones of `mt` or wildcards, and `mt` has all its
+// parameters.
+func (mr MediaRange) Specificity(mt MediaRange) int {
+	switch {
+	case mr.Type == "*" && mr.Subtype == "*":
+	case mr.Type == mt.Type && mr.Subtype == "*":
+	case mr.Type == mt.Type && mr.Subtype == mt.Subtype:
+	default:
+		return -1
+	}
+	for k, v := range mr.Params {
+		if !strings.EqualFold(mt.Params[k], v) {
+			return -1
+		}
+	}
+
+	s := len(mr.Params)
+	if mr.Type != "*" {
+		s += 100
+	}
+	if mr.Subtype != "*" {
+		s += 100
+	}
+	return s
+}
+
+// AcceptQuality returns the q-value given to the media type `mt` by the most
+// specific of the media `ranges` matching it, or 0 if none matches, so that
+// "text/*;q=0, */*" doesn't accept "text/html".
+func AcceptQuality(ranges []MediaRange, mt MediaRange) float64 {
+	q, specificity := 0.0, -1
+	for _, mr := range ranges {
+		if s := mr.Specificity(mt); s > specificity {
+			q, specificity = mr.Q, s
+		}
+	}
+	return q
+}
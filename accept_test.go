@@ -0,0 +1,63 @@
+package chi
+
+import (
+	"testing"
+)
+
+func TestParseMediaRange(t *testing.T) {
+	tests := []struct {
+		s       string
+		ok      bool
+		typ     string
+		subtype string
+		q       float64
+	}{
+		{"application/json", true, "application", "json", 1},
+		{" Text/HTML ; q=0.5", true, "text", "html", 0.5},
+		{"text/*;q=0", true, "text", "*", 0},
+		{"*", true, "*", "*", 1},
+		{"*; q=.2", true, "*", "*", 0.2},
+		{"*/json", false, "", "", 0},
+		{"text/html;q=2", false, "", "", 0},
+		{"text/html;q=x", false, "", "", 0},
+		{"text", false, "", "", 0},
+		{"", false, "", "", 0},
+	}
+	for _, tt := range tests {
+		mr, ok := ParseMediaRange(tt.s)
+		if ok != tt.ok || mr.Type != tt.typ || mr.Subtype != tt.subtype || mr.Q != tt.q {
+			t.Fatalf("'%s': expecting %v %s/%s;q=%v, got %v %+v", tt.s, tt.ok, tt.typ, tt.subtype, tt.q, ok, mr)
+		}
+		if ok && mr.Params["q"] != "" {
+			t.Fatalf("'%s': expecting the q-value to be removed from the params", tt.s)
+		}
+	}
+}
+
+func TestAcceptQuality(t *testing.T) {
+	html, _ := ParseMediaRange("text/html")
+	v2, _ := ParseMediaRange("application/vnd.app+json; version=2")
+
+	tests := []struct {
+		accept string
+		mt     MediaRange
+		q      float64
+	}{
+		{"text/html", html, 1},
+		{"text/html, */*", html, 1},
+		{"*/*;q=0.1", html, 0.1},
+		{"*;q=0.2", html, 0.2},
+		{"text/*;q=0.5, */*;q=0.1", html, 0.5},
+		{"text/*;q=0, */*", html, 0},
+		{"text/html;q=0, text/*", html, 0},
+		{"application/json", html, 0},
+		{"", html, 0},
+		{"application/vnd.app+json; version=2; q=0.8", v2, 0.8},
+		{"application/vnd.app+json; version=3, application/*;q=0.3", v2, 0.3},
+	}
+	for _, tt := range tests {
+		if q := AcceptQuality(ParseAccept(tt.accept), tt.mt); q != tt.q {
+			t.Fatalf("Accept '%s': expecting q=%v, got %v", tt.accept, tt.q, q)
+		}
+	}
+}
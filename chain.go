@@ -1,6 +1,8 @@
 package chi
 
-import "net/http"
+import (
+	"net/http"
+)
 
 // Chain returns a Middlewares type from a slice of middleware handlers.
 func Chain(middlewares ...func(Handler) Handler) Middlewares {
@@ -10,13 +12,13 @@ func Chain(middlewares ...func(Handler) Handler) Middlewares {
 // Handler builds and returns a Handler from the chain of middlewares,
 // with `h Handler` as the final handler.
 func (mws Middlewares) Handler(h Handler) Handler {
-	return &ChainHandler{mws, h, chain(mws, h)}
+	return &ChainHandler{Middlewares: mws, Endpoint: h, chain: chain(mws, h)}
 }
 
 // HandlerFunc builds and returns a Handler from the chain of middlewares,
 // with `h Handler` as the final handler.
 func (mws Middlewares) HandlerFunc(h HandlerFunc) Handler {
-	return &ChainHandler{mws, h, chain(mws, h)}
+	return &ChainHandler{Middlewares: mws, Endpoint: h, chain: chain(mws, h)}
 }
 
 // ChainHandler is a Handler with support for handler composition and
@@ -25,10 +27,20 @@ type ChainHandler struct {
 	Middlewares Middlewares
 	Endpoint    Handler
 	chain       Handler
+	traced      tracedChain
 }
 
 func (c *ChainHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) HandlerError {
-	return c.chain.ServeHTTP(w, r)
+	return c.handler(RouteContext(r.Context())).ServeHTTP(w, r)
+}
+
+// handler returns the chain to serve a request with the routing context
+// `rctx`, with its middlewares in spans if the request is traced.
+func (c *ChainHandler) handler(rctx *Context) Handler {
+	if rctx != nil && rctx.tracer != nil {
+		return c.traced.handler(c.Middlewares, c.Endpoint)
+	}
+	return c.chain
 }
 
 // chain builds a Handler composed of an inline middleware stack and endpoint
@@ -39,11 +51,60 @@ func chain(middlewares []func(Handler) Handler, endpoint Handler) Handler {
 		return endpoint
 	}
 
-	// Wrap the end handler with the middleware chain
-	h := middlewares[len(middlewares)-1](endpoint)
-	for i := len(middlewares) - 2; i >= 0; i-- {
-		h = middlewares[i](h)
+	// Wrap the end handler with the middleware chain, recording the request
+	// of an error at each middleware boundary
+	h := captureErrorRequest(endpoint)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = captureErrorRequest(middlewares[i](h))
 	}
 
 	return h
 }
+
+// captureErrorRequest wraps a handler of a middleware chain to record the
+// request on the routing context when the handler returns an error. As the
+// innermost handler returning an error records it first, the Mux error
+// handler is later called with the request as it was passed to the innermost
+// middleware or endpoint the error came from, including any context values
+// set by outer middlewares.
+func captureErrorRequest(h Handler) Handler {
+	return HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError {
+		err := h.ServeHTTP(w, r)
+		if err != nil {
+			if rctx := RouteContext(r.Context()); rctx != nil {
+				rctx.recordError(r, err)
+			}
+		}
+		return err
+	})
+}
+
+// recordError records `r` as the request that `err` was returned for, unless
+// an inner handler already recorded it for the same error.
+func (x *Context) recordError(r *http.Request, err HandlerError) {
+	if x.errorRequest != nil && sameError(x.requestErr, err) {
+		return
+	}
+	x.errorRequest, x.requestErr = r, err
+}
+
+// errorRequestFor returns the request recorded for `err`, or `r` if `err`
+// isn't the recorded error, as it was swallowed or replaced on its way out.
+func (x *Context) errorRequestFor(err HandlerError, r *http.Request) *http.Request {
+	if x.errorRequest != nil && sameError(x.requestErr, err) {
+		return x.errorRequest
+	}
+	return r
+}
+
+// sameError reports whether `a` and `b` are the same error, comparing them
+// with ==. Errors of a dynamic type that isn't comparable, such as a slice
+// type, make == panic and are never the same.
+func sameError(a, b HandlerError) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
@@ -42,6 +42,10 @@
 // matched. An anonymous regexp pattern is allowed, using an empty string
 // before the colon in the placeholder, such as {:\\d+}
 //
+// Instead of a regular expression, the name of a param matcher can follow
+// the colon, such as {id:int} or {day:date}. See RegisterParamMatcher for
+// the built-in matchers and adding custom ones.
+//
 // The special placeholder of asterisk matches the rest of the requested
 // URL. Any trailing characters in the pattern are ignored. This is the only
 // placeholder which will match / characters.
@@ -52,6 +56,7 @@
 //  "/page/*" matches "/page/intro/latest"
 //  "/page/*/index" also matches "/page/intro/latest"
 //  "/date/{yyyy:\\d\\d\\d\\d}/{mm:\\d\\d}/{dd:\\d\\d}" matches "/date/2017/04/01"
+//  "/user/{id:int}" matches "/user/42" but not "/user/jsmith"
 //
 package chi
 
@@ -78,9 +83,25 @@ type Router interface {
 	// path, with a fresh middleware stack for the inline-Router.
 	Group(fn func(r Router)) Router
 
+	// Named adds a new inline-Router that registers its route under
+	// `name`, for building its URL with URLFor.
+	Named(name string) Router
+
+	// When adds a new inline-Router that registers its routes with
+	// additional conditions on the request.
+	When(conditions ...RouteCondition) Router
+
+	// WithMeta adds a new inline-Router that registers its routes with
+	// the metadata `meta`.
+	WithMeta(meta Meta) Router
+
 	// Route mounts a sub-Router along a `pattern`` string.
 	Route(pattern string, fn func(r Router)) Router
 
+	// Host adds a new Router serving the requests to hosts matching
+	// `pattern`, such as "{tenant}.example.com".
+	Host(pattern string, fn func(r Router)) Router
+
 	// Mount attaches another Handler along ./pattern/*
 	Mount(pattern string, h Handler)
 
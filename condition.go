@@ -0,0 +1,154 @@
+package chi
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// RouteCondition is a condition on the request that a route registered with
+// Mux.When needs to satisfy, in addition to its method and pattern.
+type RouteCondition struct {
+	match func(r *http.Request) bool
+
+	// status of the response if no route of the path satisfies its
+	// conditions because of this condition
+	status int
+}
+
+// HeaderEquals returns a RouteCondition satisfied if the value of the request
+// header `name` equals `value`.
+func HeaderEquals(name, value string) RouteCondition {
+	return ConditionFunc(func(r *http.Request) bool {
+		return r.Header.Get(name) == value
+	})
+}
+
+// HeaderPrefix returns a RouteCondition satisfied if the value of the request
+// header `name` starts with `prefix`.
+func HeaderPrefix(name, prefix string) RouteCondition {
+	return ConditionFunc(func(r *http.Request) bool {
+		return strings.HasPrefix(r.Header.Get(name), prefix)
+	})
+}
+
+// QueryPresent returns a RouteCondition satisfied if the query string of the
+// request has the param `key`, with or without a value.
+func QueryPresent(key string) RouteCondition {
+	return ConditionFunc(func(r *http.Request) bool {
+		_, ok := r.URL.Query()[key]
+		return ok
+	})
+}
+
+// ContentType returns a RouteCondition satisfied if the media type of the
+// request's Content-Type header is one of `contentTypes`, such as
+// "application/json". If no route of the path is satisfied because of its
+// content type, the request is answered with a 415 Unsupported Media Type.
+func ContentType(contentTypes ...string) RouteCondition {
+	allowed := make(map[string]struct{}, len(contentTypes))
+	for _, ct := range contentTypes {
+		allowed[strings.ToLower(ct)] = struct{}{}
+	}
+	return RouteCondition{
+		match: func(r *http.Request) bool {
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil {
+				return false
+			}
+			_, ok := allowed[mediaType]
+			return ok
+		},
+		status: http.StatusUnsupportedMediaType,
+	}
+}
+
+// Accepts returns a RouteCondition satisfied if the request's Accept header
+// accepts one of `contentTypes`, or if there's no Accept header. A media type
+// is accepted if the most specific media range matching it has a q-value
+// above 0, see AcceptQuality. If no route of the path is satisfied because of
+// the Accept header, the request is answered with a 406 Not Acceptable.
+func Accepts(contentTypes ...string) RouteCondition {
+	var offers []MediaRange
+	for _, ct := range contentTypes {
+		if mt, ok := ParseMediaRange(ct); ok {
+			offers = append(offers, mt)
+		}
+	}
+	return RouteCondition{
+		match: func(r *http.Request) bool {
+			accept := r.Header.Get("Accept")
+			if accept == "" {
+				return true
+			}
+			ranges := ParseAccept(accept)
+			for _, mt := range offers {
+				if AcceptQuality(ranges, mt) > 0 {
+					return true
+				}
+			}
+			return false
+		},
+		status: http.StatusNotAcceptable,
+	}
+}
+
+// ConditionFunc returns a RouteCondition satisfied if `fn` returns true for
+// the request.
+func ConditionFunc(fn func(r *http.Request) bool) RouteCondition {
+	return RouteCondition{match: fn, status: http.StatusNotFound}
+}
+
+// conditionalEndpoint is a handler of an endpoint that is only routed to if
+// the request satisfies its conditions.
+type conditionalEndpoint struct {
+	conditions []RouteCondition
+	handler    Handler
+	meta       Meta
+}
+
+// match returns the handler and metadata of the first conditional endpoint
+// satisfied by the request, or the unconditional handler. Without a handler,
+// it returns the status of the response: 415 if a Content-Type condition
+// failed, 406 if an Accept condition failed and 404 otherwise.
+func (e *endpoint) match(r *http.Request) (Handler, Meta, int) {
+	status := http.StatusNotFound
+	for _, ce := range e.conditional {
+		satisfied := true
+		for _, c := range ce.conditions {
+			if !c.match(r) {
+				satisfied = false
+				status = conditionStatus(status, c.status)
+				break
+			}
+		}
+		if satisfied {
+			return ce.handler, ce.meta, 0
+		}
+	}
+	if e.handler != nil {
+		return e.handler, e.meta, 0
+	}
+	return nil, nil, status
+}
+
+// conditionStatus returns the status with the higher precedence for an
+// unsatisfied route, a 415 over a 406 over a 404.
+func conditionStatus(a, b int) int {
+	for _, status := range []int{http.StatusUnsupportedMediaType, http.StatusNotAcceptable} {
+		if a == status || b == status {
+			return status
+		}
+	}
+	return http.StatusNotFound
+}
+
+// ServeHTTP serves the request with the handler matching the request, see
+// match.
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) HandlerError {
+	h, _, status := e.match(r)
+	if h == nil {
+		return Error{Code: status}
+	}
+	return h.ServeHTTP(w, r)
+}
@@ -0,0 +1,125 @@
+package chi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMuxWhen(t *testing.T) {
+	hBody := func(body string) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) HandlerError {
+			w.Write([]byte(body))
+			return nil
+		}
+	}
+
+	r := NewRouter()
+	r.When(ContentType("application/json")).Post("/articles", hBody("create json"))
+	r.When(ContentType("multipart/form-data")).Post("/articles", hBody("create form"))
+
+	r.When(HeaderEquals("X-Version", "2")).Get("/articles", hBody("list v2"))
+	r.When(HeaderPrefix("User-Agent", "curl/")).Get("/articles", hBody("list curl"))
+	r.Get("/articles", hBody("list"))
+
+	r.When(Accepts("text/csv")).Get("/reports/{id}", hBody("report csv"))
+	r.When(Accepts("application/json"), QueryPresent("pretty")).Get("/reports/{id}", hBody("report pretty json"))
+	r.When(Accepts("application/json")).Get("/reports/{id}", hBody("report json"))
+
+	r.Group(func(r Router) {
+		r = r.When(QueryPresent("debug"))
+		r.Get("/debug/vars", hBody("vars"))
+		r.With(func(next Handler) Handler { return next }).Get("/debug/stack", hBody("stack"))
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	tests := []struct {
+		method  string
+		path    string
+		headers map[string]string
+		status  int
+		body    string
+	}{
+		{"POST", "/articles", map[string]string{"Content-Type": "application/json; charset=utf-8"}, 200, "create json"},
+		{"POST", "/articles", map[string]string{"Content-Type": "multipart/form-data; boundary=x"}, 200, "create form"},
+		{"POST", "/articles", map[string]string{"Content-Type": "text/plain"}, 415, "Unsupported Media Type\n"},
+		{"POST", "/articles", nil, 415, "Unsupported Media Type\n"},
+
+		{"GET", "/articles", map[string]string{"X-Version": "2", "User-Agent": "curl/7.0"}, 200, "list v2"},
+		{"GET", "/articles", map[string]string{"User-Agent": "curl/7.0"}, 200, "list curl"},
+		{"GET", "/articles", map[string]string{"X-Version": "3"}, 200, "list"},
+
+		{"GET", "/reports/1", map[string]string{"Accept": "text/csv"}, 200, "report csv"},
+		{"GET", "/reports/1", map[string]string{"Accept": "text/html, application/*;q=0.5"}, 200, "report json"},
+		{"GET", "/reports/1?pretty", map[string]string{"Accept": "application/json"}, 200, "report pretty json"},
+		{"GET", "/reports/1", map[string]string{"Accept": "text/csv;q=0, application/xml"}, 406, "Not Acceptable\n"},
+		{"GET", "/reports/1", map[string]string{"Accept": "text/*;q=0, */*"}, 200, "report json"},
+		{"GET", "/reports/1", map[string]string{"Accept": "*"}, 200, "report csv"},
+		{"GET", "/reports/1", nil, 200, "report csv"},
+
+		{"GET", "/debug/vars?debug=1", nil, 200, "vars"},
+		{"GET", "/debug/stack?debug", nil, 200, "stack"},
+		{"GET", "/debug/vars", nil, 404, "Not Found\n"},
+		{"PUT", "/debug/vars", nil, 405, "Method Not Allowed\n"},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader(""))
+		for k, v := range tt.headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status || string(body) != tt.body {
+			t.Fatalf("%s %s %v: expecting %d with '%s', got %d with '%s'", tt.method, tt.path, tt.headers, tt.status, tt.body, resp.StatusCode, body)
+		}
+	}
+
+	var routes []string
+	Walk(r, func(method string, route string, handler Handler, middlewares ...func(Handler) Handler) error {
+		routes = append(routes, method+" "+route)
+		return nil
+	})
+	if len(routes) != 10 {
+		t.Fatalf("expecting Walk to list each handler of a method and pattern, got %v", routes)
+	}
+}
+
+func TestMuxWhenWalk(t *testing.T) {
+	mw := func(next Handler) Handler { return next }
+	h := func(w http.ResponseWriter, r *http.Request) HandlerError { return nil }
+
+	r := NewRouter()
+	r.When(Accepts("text/csv")).With(mw).WithMeta(Meta{"format": "csv"}).Get("/reports", h)
+	r.WithMeta(Meta{"format": "json"}).Get("/reports", h)
+
+	var visited []string
+	WalkMeta(r, func(method string, route string, handler Handler, meta Meta, middlewares ...func(Handler) Handler) error {
+		visited = append(visited, fmt.Sprintf("%s %s %v %d", method, route, meta["format"], len(middlewares)))
+		return nil
+	})
+	expected := []string{"GET /reports json 0", "GET /reports csv 1"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Fatalf("expecting %v, got %v", expected, visited)
+	}
+}
+
+func TestMuxWhenMountPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expecting panic when mounting with route conditions")
+		}
+	}()
+	r := NewRouter()
+	r.When(QueryPresent("x")).Mount("/sub", NewRouter())
+}
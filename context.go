@@ -30,6 +30,25 @@ func RouteContext(ctx context.Context) *Context {
 	return val
 }
 
+// PassError passes `err` on from the error handler of a sub-router to the
+// error handler of its parent router, instead of handling it. The error
+// handler must not write a response when passing an error on.
+//
+// For example,
+//
+//   adminRouter.Error(func(err chi.HandlerError, w http.ResponseWriter, r *http.Request) {
+//     if err.StatusCode() >= 500 {
+//       chi.PassError(r, err)
+//       return
+//     }
+//     renderErrorPage(w, err)
+//   })
+func PassError(r *http.Request, err HandlerError) {
+	if rctx := RouteContext(r.Context()); rctx != nil {
+		rctx.passedError = err
+	}
+}
+
 // ServerBaseContext wraps an http.Handler to set the request context to the
 // `baseCtx`.
 func ServerBaseContext(baseCtx context.Context, h Handler) Handler {
@@ -65,10 +84,11 @@ var (
 type Context struct {
 	Routes Routes
 
-	// Routing path/method override used during the route search.
+	// Routing path/method/host override used during the route search.
 	// See Mux#routeHTTP method.
 	RoutePath   string
 	RouteMethod string
+	RouteHost   string
 
 	// Routing pattern stack throughout the lifecycle of the request,
 	// across all connected routers. It is a record of all matching
@@ -91,6 +111,39 @@ type Context struct {
 
 	// methodNotAllowed hint
 	methodNotAllowed bool
+
+	// methodsAllowed are the methods routed for the path when
+	// methodNotAllowed is set.
+	methodsAllowed methodTyp
+
+	// autoHead routes HEAD requests to GET endpoints, see Mux.AutoHead.
+	autoHead bool
+
+	// caseInsensitive matches static segments of routing patterns regardless
+	// of case, and caseFolded is set once it did. See Mux.CaseSensitivity.
+	caseInsensitive bool
+	caseFolded      bool
+
+	// foldedPath is the routing path of the outermost router that matched
+	// the request path in another case than its routing pattern, and
+	// canonicalPath is the same path in the case of the routing patterns.
+	foldedPath    string
+	canonicalPath string
+
+	// routeMeta is the metadata of the matched routes, see RouteMeta.
+	routeMeta Meta
+
+	// tracer is the Tracer of the root router, see Mux.Tracer.
+	tracer Tracer
+
+	// errorRequest is the request as seen by the innermost handler that
+	// returned the error requestErr. See captureErrorRequest.
+	errorRequest *http.Request
+	requestErr   HandlerError
+
+	// passedError is the error passed on to the parent router's error
+	// handler. See PassError.
+	passedError HandlerError
 }
 
 // Reset a routing context to its initial state.
@@ -98,6 +151,7 @@ func (x *Context) Reset() {
 	x.Routes = nil
 	x.RoutePath = ""
 	x.RouteMethod = ""
+	x.RouteHost = ""
 	x.RoutePatterns = x.RoutePatterns[:0]
 	x.URLParams.Keys = x.URLParams.Keys[:0]
 	x.URLParams.Values = x.URLParams.Values[:0]
@@ -106,6 +160,30 @@ func (x *Context) Reset() {
 	x.routeParams.Keys = x.routeParams.Keys[:0]
 	x.routeParams.Values = x.routeParams.Values[:0]
 	x.methodNotAllowed = false
+	x.methodsAllowed = 0
+	x.autoHead = false
+	x.caseInsensitive = false
+	x.caseFolded = false
+	x.foldedPath = ""
+	x.canonicalPath = ""
+	x.routeMeta = nil
+	x.tracer = nil
+	x.errorRequest = nil
+	x.requestErr = nil
+	x.passedError = nil
+}
+
+// Clone returns a copy of the routing context that shares no state with x.
+// It's useful for handing the routing context to a handler running on
+// another goroutine, which may outlive the request x belongs to.
+func (x *Context) Clone() *Context {
+	c := *x
+	c.RoutePatterns = append([]string(nil), x.RoutePatterns...)
+	c.URLParams.Keys = append([]string(nil), x.URLParams.Keys...)
+	c.URLParams.Values = append([]string(nil), x.URLParams.Values...)
+	c.routeParams.Keys = append([]string(nil), x.routeParams.Keys...)
+	c.routeParams.Values = append([]string(nil), x.routeParams.Values...)
+	return &c
 }
 
 // URLParam returns the corresponding URL parameter value from the request
@@ -119,6 +197,12 @@ func (x *Context) URLParam(key string) string {
 	return ""
 }
 
+// AllowedMethods returns the methods routed for the request path when no
+// route matched the request method, such as in a MethodNotAllowed handler.
+func (x *Context) AllowedMethods() []string {
+	return methodTypStrings(x.methodsAllowed)
+}
+
 // RoutePattern builds the routing pattern string for the particular
 // request, at the particular point during routing. This means, the value
 // will change throughout the execution of a request in a router. That is
@@ -133,6 +217,9 @@ func (x *Context) URLParam(key string) string {
 //       measure(w, r, routePattern)
 //   	 })
 //   }
+//
+// The Metrics type of the middleware package records request metrics by
+// routing pattern this way.
 func (x *Context) RoutePattern() string {
 	routePattern := strings.Join(x.RoutePatterns, "")
 	return replaceWildcards(routePattern)
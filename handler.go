@@ -1,6 +1,10 @@
 package chi
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+	"sync"
+)
 
 type Handler interface {
 	ServeHTTP(http.ResponseWriter, *http.Request) HandlerError
@@ -12,15 +16,56 @@ func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) HandlerEr
 	return f(w, r)
 }
 
+// ToHTTPFunc returns a http.HandlerFunc that handles errors returned by
+// `f` with DefaultErrorHandler. See ToHTTPHandlerFunc.
 func (f HandlerFunc) ToHTTPFunc() http.HandlerFunc {
+	return ToHTTPHandlerFunc(f, nil)
+}
+
+// ToHTTPHandler converts `h` into a http.Handler, so that any Handler can
+// be served by the standard library or used with third-party routers.
+// Errors returned by `h` are handled by `errorHandler`, or by
+// DefaultErrorHandler if `errorHandler` is nil.
+//
+// For a Mux, the error handler is called before the routing context is
+// released, just as with Mux.ToHTTPHandler, and a nil `errorHandler` falls
+// back to the one set with Mux.Error.
+func ToHTTPHandler(h Handler, errorHandler ErrorHandlerFunc) http.Handler {
+	if mx, ok := h.(*Mux); ok {
+		if errorHandler == nil {
+			return mx.ToHTTPHandler()
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mx.serveHTTP(w, r, errorHandler)
+		})
+	}
+	errorHandler = resolveErrorHandler(errorHandler)
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		err := f(w, r)
-		if err != nil {
-			panic("chi: an unhandled error occurred")
+		if err := h.ServeHTTP(w, r); err != nil {
+			errorHandler(err, w, r)
 		}
 	})
 }
 
+// ToHTTPHandlerFunc converts `h` into a http.HandlerFunc. Errors returned
+// by `h` are handled by `errorHandler`, or by DefaultErrorHandler if
+// `errorHandler` is nil.
+func ToHTTPHandlerFunc(h HandlerFunc, errorHandler ErrorHandlerFunc) http.HandlerFunc {
+	errorHandler = resolveErrorHandler(errorHandler)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := h(w, r); err != nil {
+			errorHandler(err, w, r)
+		}
+	})
+}
+
+func resolveErrorHandler(errorHandler ErrorHandlerFunc) ErrorHandlerFunc {
+	if errorHandler != nil {
+		return errorHandler
+	}
+	return DefaultErrorHandler
+}
+
 func FromHTTPHandler(h http.Handler) Handler {
 	return HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError {
 		h.ServeHTTP(w, r)
@@ -35,6 +80,76 @@ func FromHTTPHandlerFunc(h http.HandlerFunc) HandlerFunc {
 	})
 }
 
+// FromHTTPMiddleware converts a standard net/http middleware, such as
+// CORS, authentication or tracing middlewares from third-party packages,
+// into a chi middleware that can be passed to Mux.Use or Mux.With.
+//
+// Although the standard middleware signature has no way of returning an
+// error, the HandlerError of the wrapped chi handlers is passed through and
+// returned to the parent handler once the standard middleware is done.
+// Note that the standard middleware itself sees an unwritten response for
+// the error, as it is only rendered later by the error handler, so a logging
+// or metrics middleware wrapped this way records a 200 for it.
+//
+// The standard middleware may serve the request on another goroutine, as
+// http.TimeoutHandler does. An error returned by the wrapped chi handlers
+// after the standard middleware is done is dropped, as the middleware
+// already responded to the request.
+//
+// If the standard middleware serves the request with a context that is not
+// derived from the original request context, errors can't be passed through
+// and are handled by DefaultErrorHandler instead.
+func FromHTTPMiddleware(mw func(http.Handler) http.Handler) func(Handler) Handler {
+	return func(next Handler) Handler {
+		h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err := next.ServeHTTP(w, r)
+			if err == nil {
+				return
+			}
+			if ec, ok := r.Context().Value(httpMiddlewareErrorCtxKey).(*httpMiddlewareError); ok {
+				ec.set(err)
+				return
+			}
+			DefaultErrorHandler(err, w, r)
+		}))
+
+		return HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError {
+			ec := &httpMiddlewareError{}
+			h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), httpMiddlewareErrorCtxKey, ec)))
+			return ec.finish()
+		})
+	}
+}
+
+// httpMiddlewareErrorCtxKey is the context.Context key to carry the error of
+// the inner handler out of a standard middleware. See FromHTTPMiddleware.
+var httpMiddlewareErrorCtxKey = &contextKey{"HTTPMiddlewareError"}
+
+// httpMiddlewareError is the error of the inner handler of a standard
+// middleware, which may be set from another goroutine.
+type httpMiddlewareError struct {
+	mu   sync.Mutex
+	err  HandlerError
+	done bool
+}
+
+// set sets the error, unless the standard middleware is done.
+func (ec *httpMiddlewareError) set(err HandlerError) {
+	ec.mu.Lock()
+	if !ec.done {
+		ec.err = err
+	}
+	ec.mu.Unlock()
+}
+
+// finish marks the standard middleware as done and returns the error.
+func (ec *httpMiddlewareError) finish() HandlerError {
+	ec.mu.Lock()
+	defer ec.mu.Unlock()
+	ec.done = true
+	return ec.err
+}
+
 type HandlerError interface {
 	error
 	StatusCode() int
@@ -49,6 +164,11 @@ func (e Error) StatusCode() int {
 	return e.Code
 }
 
+// Unwrap returns the underlying error.
+func (e Error) Unwrap() error {
+	return e.Err
+}
+
 func (e Error) Error() string {
 	if e.Err != nil {
 		return e.Err.Error()
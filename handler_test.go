@@ -0,0 +1,166 @@
+package chi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestToHTTPHandler(t *testing.T) {
+	failing := HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError {
+		return Error{Code: http.StatusBadRequest, Err: errors.New("bad input")}
+	})
+
+	// Default error handler
+	w := httptest.NewRecorder()
+	failing.ToHTTPFunc().ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != 400 || w.Body.String() != "bad input\n" {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Body.String())
+	}
+
+	// Custom error handler
+	errorHandler := func(err HandlerError, w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(err.StatusCode())
+		w.Write([]byte("custom: " + err.Error()))
+	}
+	w = httptest.NewRecorder()
+	ToHTTPHandler(failing, errorHandler).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != 400 || w.Body.String() != "custom: bad input" {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	ToHTTPHandlerFunc(failing, errorHandler).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != 400 || w.Body.String() != "custom: bad input" {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Body.String())
+	}
+
+	// Mux with access to the routing context
+	r := NewRouter()
+	r.Get("/{id}", failing)
+	w = httptest.NewRecorder()
+	ToHTTPHandler(r, func(err HandlerError, w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(err.StatusCode())
+		w.Write([]byte(URLParam(r, "id")))
+	}).ServeHTTP(w, httptest.NewRequest("GET", "/5", nil))
+	if w.Code != 400 || w.Body.String() != "5" {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Body.String())
+	}
+
+	// Mux with its own error handler
+	r.Error(errorHandler)
+	w = httptest.NewRecorder()
+	ToHTTPHandler(r, nil).ServeHTTP(w, httptest.NewRequest("GET", "/5", nil))
+	if w.Code != 400 || w.Body.String() != "custom: bad input" {
+		t.Fatalf("unexpected response %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestFromHTTPMiddleware(t *testing.T) {
+	var seen string
+	stdmw := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Std", "yes")
+			r = r.WithContext(context.WithValue(r.Context(), ctxKey{"std"}, "value"))
+			next.ServeHTTP(w, r)
+			seen = r.URL.Path
+		})
+	}
+	denymw := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("deny") != "" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	r := NewRouter()
+	r.Use(FromHTTPMiddleware(stdmw), FromHTTPMiddleware(denymw))
+	r.Error(func(err HandlerError, w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(err.StatusCode())
+		w.Write([]byte("error: " + err.Error()))
+	})
+	r.Get("/ok", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		w.Write([]byte(r.Context().Value(ctxKey{"std"}).(string)))
+		return nil
+	})
+	r.Get("/fail", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		return Error{Code: http.StatusConflict, Err: errors.New("conflict")}
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	testcases := []struct {
+		Path           string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{"/ok", 200, "value"},
+		{"/fail", 409, "error: conflict"},
+		{"/nothing", 404, "error: Not Found"},
+		{"/fail?deny=1", 403, ""},
+	}
+
+	for _, tc := range testcases {
+		resp, body := testRequest(t, ts, "GET", tc.Path, nil)
+		if resp.StatusCode != tc.ExpectedStatus || body != tc.ExpectedBody {
+			t.Fatalf("%s: expecting %d %q, got %d %q", tc.Path, tc.ExpectedStatus, tc.ExpectedBody, resp.StatusCode, body)
+		}
+		if resp.Header.Get("X-Std") != "yes" {
+			t.Fatalf("%s: expecting X-Std header", tc.Path)
+		}
+		if seen == "" {
+			t.Fatalf("%s: expecting std middleware to complete", tc.Path)
+		}
+		seen = ""
+	}
+}
+
+func TestFromHTTPMiddlewareGoroutine(t *testing.T) {
+	asyncmw := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				next.ServeHTTP(w, r)
+			}()
+			<-done
+		})
+	}
+	timeoutmw := func(next http.Handler) http.Handler {
+		return http.TimeoutHandler(next, 50*time.Millisecond, "timeout")
+	}
+
+	r := NewRouter()
+	r.Error(func(err HandlerError, w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(err.StatusCode())
+		w.Write([]byte("error: " + err.Error()))
+	})
+	r.With(FromHTTPMiddleware(asyncmw)).Get("/async", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		return Error{Code: http.StatusConflict}
+	})
+	lateErr := make(chan struct{})
+	r.With(FromHTTPMiddleware(timeoutmw)).Get("/timeout", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		<-r.Context().Done()
+		time.Sleep(50 * time.Millisecond)
+		defer close(lateErr)
+		return Error{Code: http.StatusConflict}
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	if resp, body := testRequest(t, ts, "GET", "/async", nil); resp.StatusCode != 409 || body != "error: Conflict" {
+		t.Fatalf("expecting the error of the handler, got %d %q", resp.StatusCode, body)
+	}
+	if resp, body := testRequest(t, ts, "GET", "/timeout", nil); resp.StatusCode != 503 || body != "timeout" {
+		t.Fatalf("expecting the timeout response, got %d %q", resp.StatusCode, body)
+	}
+	<-lateErr
+}
@@ -0,0 +1,83 @@
+package chi
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Host creates a new Mux with a fresh middleware stack, serving the requests
+// to hosts matching `pattern`. Requests to other hosts continue routing at
+// the routes of the Mux itself.
+//
+// The host pattern uses the syntax of routing patterns without the slashes,
+// such as "{tenant}.example.com" or "api.{domain:alpha}.com". Host params are
+// available through URLParam, and the host pattern is prepended to the route
+// pattern, see Context.RoutePattern and Walk. Hosts are matched regardless of
+// case and port.
+//
+//  r.Host("{tenant}.example.com", func(r chi.Router) {
+//    r.Get("/", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+//      w.Write([]byte("welcome " + chi.URLParam(r, "tenant")))
+//      return nil
+//    })
+//  })
+func (mx *Mux) Host(pattern string, fn func(r Router)) Router {
+	if pattern == "" || pattern[0] == '/' {
+		panic(fmt.Sprintf("chi: host pattern must not be empty or begin with '/' in '%s'", pattern))
+	}
+
+	m := mx
+	for m.inline && m.parent != nil {
+		m = m.parent
+	}
+	if m.handler == nil {
+		m.buildRouteHandler()
+	}
+	if m.hosts == nil {
+		m.hosts = &node{}
+	}
+	if m.hosts.findPattern(pattern) {
+		panic(fmt.Sprintf("chi: attempting to route host '%s' twice", pattern))
+	}
+
+	subRouter := NewRouter()
+	if fn != nil {
+		fn(subRouter)
+	}
+	mx.checkRouteNames(pattern, subRouter)
+	mx.configureSubRouter(subRouter)
+
+	var h Handler = subRouter
+	if mx.inline {
+		h = Chain(mx.middlewares...).Handler(h)
+	}
+	m.hosts.InsertRoute(mALL, pattern, h).subroutes = subRouter
+
+	return subRouter
+}
+
+// findHost returns the sub-router and handler of the host pattern matching
+// `host`, and records its params in the routing context.
+func (mx *Mux) findHost(rctx *Context, host string) (Routes, Handler) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(host, ".")
+
+	rctx.caseInsensitive = true
+	n, _, h := mx.hosts.FindRoute(rctx, mGET, host)
+	if h == nil {
+		return nil, nil
+	}
+	return n.subroutes, h
+}
+
+// routeHost returns the host of the request routed with the routing context.
+func routeHost(rctx *Context, r *http.Request) string {
+	if rctx.RouteHost != "" {
+		return rctx.RouteHost
+	}
+	return r.Host
+}
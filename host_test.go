@@ -0,0 +1,150 @@
+package chi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMuxHost(t *testing.T) {
+	r := NewRouter()
+	r.Host("{tenant}.example.com", func(r Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) HandlerError {
+			w.Write([]byte("tenant " + URLParam(r, "tenant")))
+			return nil
+		})
+		r.Route("/users", func(r Router) {
+			r.Named("user").Get("/{id}", func(w http.ResponseWriter, r *http.Request) HandlerError {
+				rctx := RouteContext(r.Context())
+				w.Write([]byte(URLParam(r, "tenant") + " user " + URLParam(r, "id") + " " + rctx.RoutePattern()))
+				return nil
+			})
+		})
+	})
+	r.Host("api.example.com", func(r Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) HandlerError {
+			w.Write([]byte("api"))
+			return nil
+		})
+	})
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		w.Write([]byte("default"))
+		return nil
+	})
+
+	tests := []struct {
+		host   string
+		path   string
+		status int
+		body   string
+	}{
+		{"acme.example.com", "/", 200, "tenant acme"},
+		{"Acme.Example.com:8080", "/", 200, "tenant Acme"},
+		{"acme.example.com", "/users/42", 200, "acme user 42 {tenant}.example.com/users/{id}"},
+		{"api.example.com", "/", 200, "api"},
+		{"api.example.com.", "/", 200, "api"},
+		{"acme.example.com", "/nothing", 404, "Not Found\n"},
+		{"example.com", "/", 200, "default"},
+		{"a.b.example.com", "/", 200, "default"},
+		{"localhost:3333", "/", 200, "default"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		r.ToHTTPHandler().ServeHTTP(w, req)
+
+		if w.Code != tt.status || w.Body.String() != tt.body {
+			t.Fatalf("%s%s: expecting %d with '%s', got %d with '%s'", tt.host, tt.path, tt.status, tt.body, w.Code, w.Body.String())
+		}
+	}
+
+	if url, err := r.URLFor("user", "tenant", "acme", "id", "1"); err != nil || url != "//acme.example.com/users/1" {
+		t.Fatalf("expecting '//acme.example.com/users/1', got '%s' (%v)", url, err)
+	}
+
+	var routes []string
+	Walk(r, func(method string, route string, handler Handler, middlewares ...func(Handler) Handler) error {
+		routes = append(routes, method+" "+route)
+		return nil
+	})
+	for _, expected := range []string{"GET /", "GET {tenant}.example.com/", "GET {tenant}.example.com/users/{id}", "GET api.example.com/"} {
+		found := false
+		for _, route := range routes {
+			found = found || route == expected
+		}
+		if !found {
+			t.Fatalf("expecting Walk to list '%s', got %s", expected, strings.Join(routes, ", "))
+		}
+	}
+}
+
+func TestMuxHostMounted(t *testing.T) {
+	api := NewRouter()
+	api.Host("{version}.api.example.com", func(r Router) {
+		r.Get("/ping", func(w http.ResponseWriter, r *http.Request) HandlerError {
+			w.Write([]byte("pong " + URLParam(r, "version")))
+			return nil
+		})
+	})
+
+	r := NewRouter()
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) HandlerError {
+		w.WriteHeader(404)
+		w.Write([]byte("custom not found"))
+		return nil
+	})
+	r.Mount("/api", api)
+
+	req := httptest.NewRequest("GET", "/api/ping", nil)
+	req.Host = "v2.api.example.com"
+	w := httptest.NewRecorder()
+	r.ToHTTPHandler().ServeHTTP(w, req)
+	if w.Body.String() != "pong v2" {
+		t.Fatalf("expecting 'pong v2', got '%s'", w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/api/nothing", nil)
+	req.Host = "v2.api.example.com"
+	w = httptest.NewRecorder()
+	r.ToHTTPHandler().ServeHTTP(w, req)
+	if w.Code != 404 || w.Body.String() != "custom not found" {
+		t.Fatalf("expecting the parent's not found handler, got %d with '%s'", w.Code, w.Body.String())
+	}
+}
+
+func TestMuxHostMatchAndPathPolicy(t *testing.T) {
+	r := NewRouter()
+	r.Host("api.example.com", func(r Router) {
+		r.Get("/users", func(w http.ResponseWriter, r *http.Request) HandlerError {
+			w.Write([]byte("users"))
+			return nil
+		})
+	})
+	r.PathPolicy(PathRedirectPermanent)
+
+	rctx := NewRouteContext()
+	rctx.RouteHost = "api.example.com:8080"
+	if !r.Match(rctx, "GET", "/users") {
+		t.Fatal("expecting GET /users to match on api.example.com")
+	}
+	if r.Match(NewRouteContext(), "GET", "/users") {
+		t.Fatal("expecting GET /users not to match without a host")
+	}
+
+	req := httptest.NewRequest("GET", "/users/", nil)
+	req.Host = "api.example.com"
+	w := httptest.NewRecorder()
+	r.ToHTTPHandler().ServeHTTP(w, req)
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/users" {
+		t.Fatalf("expecting a redirect to /users, got %d to '%s'", w.Code, w.Header().Get("Location"))
+	}
+
+	r.PathPolicy(PathStrip)
+	w = httptest.NewRecorder()
+	r.ToHTTPHandler().ServeHTTP(w, req)
+	if w.Code != 200 || w.Body.String() != "users" {
+		t.Fatalf("expecting 'users', got %d with '%s'", w.Code, w.Body.String())
+	}
+}
@@ -3,30 +3,30 @@ package middleware
 import (
 	"fmt"
 	"net/http"
+
+	"github.com/SirAiedail/chi"
 )
 
 // BasicAuth implements a simple middleware handler for adding basic http auth to a route.
-func BasicAuth(realm string, creds map[string]string) func(next http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+func BasicAuth(realm string, creds map[string]string) func(next chi.Handler) chi.Handler {
+	return func(next chi.Handler) chi.Handler {
+		return chi.HandlerFunc(func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
 			user, pass, ok := r.BasicAuth()
 			if !ok {
-				basicAuthFailed(w, realm)
-				return
+				return basicAuthFailed(w, realm)
 			}
 
 			credPass, credUserOk := creds[user]
 			if !credUserOk || pass != credPass {
-				basicAuthFailed(w, realm)
-				return
+				return basicAuthFailed(w, realm)
 			}
 
-			next.ServeHTTP(w, r)
+			return next.ServeHTTP(w, r)
 		})
 	}
 }
 
-func basicAuthFailed(w http.ResponseWriter, realm string) {
+func basicAuthFailed(w http.ResponseWriter, realm string) chi.HandlerError {
 	w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s"`, realm))
-	w.WriteHeader(http.StatusUnauthorized)
+	return chi.Error{Code: http.StatusUnauthorized}
 }
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SirAiedail/chi"
+)
+
+func TestBasicAuth(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(BasicAuth("test", map[string]string{"user": "pass"}))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		w.Write([]byte("ok"))
+		return nil
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	resp, _ := testRequest(t, ts, "GET", "/", nil)
+	assertEqual(t, http.StatusUnauthorized, resp.StatusCode)
+	assertEqual(t, `Basic realm="test"`, resp.Header.Get("WWW-Authenticate"))
+
+	req, _ := http.NewRequest("GET", ts.URL+"/", nil)
+	req.SetBasicAuth("user", "wrong")
+	resp, err := http.DefaultClient.Do(req)
+	assertNoError(t, err)
+	resp.Body.Close()
+	assertEqual(t, http.StatusUnauthorized, resp.StatusCode)
+
+	req, _ = http.NewRequest("GET", ts.URL+"/", nil)
+	req.SetBasicAuth("user", "pass")
+	resp, err = http.DefaultClient.Do(req)
+	assertNoError(t, err)
+	resp.Body.Close()
+	assertEqual(t, http.StatusOK, resp.StatusCode)
+}
@@ -3,33 +3,34 @@ package middleware
 import (
 	"net/http"
 	"strings"
+
+	"github.com/SirAiedail/chi"
 )
 
 // SetHeader is a convenience handler to set a response header key/value
-func SetHeader(key, value string) func(next http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		fn := func(w http.ResponseWriter, r *http.Request) {
+func SetHeader(key, value string) func(next chi.Handler) chi.Handler {
+	return func(next chi.Handler) chi.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
 			w.Header().Set(key, value)
-			next.ServeHTTP(w, r)
+			return next.ServeHTTP(w, r)
 		}
-		return http.HandlerFunc(fn)
+		return chi.HandlerFunc(fn)
 	}
 }
 
 // AllowContentType enforces a whitelist of request Content-Types otherwise responds
 // with a 415 Unsupported Media Type status.
-func AllowContentType(contentTypes ...string) func(next http.Handler) http.Handler {
+func AllowContentType(contentTypes ...string) func(next chi.Handler) chi.Handler {
 	cT := []string{}
 	for _, t := range contentTypes {
 		cT = append(cT, strings.ToLower(t))
 	}
 
-	return func(next http.Handler) http.Handler {
-		fn := func(w http.ResponseWriter, r *http.Request) {
+	return func(next chi.Handler) chi.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
 			if r.ContentLength == 0 {
 				// skip check for empty content body
-				next.ServeHTTP(w, r)
-				return
+				return next.ServeHTTP(w, r)
 			}
 
 			s := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Type")))
@@ -39,13 +40,12 @@ func AllowContentType(contentTypes ...string) func(next http.Handler) http.Handl
 
 			for _, t := range cT {
 				if t == s {
-					next.ServeHTTP(w, r)
-					return
+					return next.ServeHTTP(w, r)
 				}
 			}
 
-			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return chi.Error{Code: http.StatusUnsupportedMediaType}
 		}
-		return http.HandlerFunc(fn)
+		return chi.HandlerFunc(fn)
 	}
 }
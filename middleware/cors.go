@@ -0,0 +1,311 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SirAiedail/chi"
+)
+
+// CORSOpts represents a set of CORS options.
+type CORSOpts struct {
+	// AllowedOrigins is a list of origins a cross-domain request can be
+	// executed from. An origin may contain a single "*" wildcard, such as
+	// "https://*.example.com", and "*" alone allows all origins.
+	AllowedOrigins []string
+
+	// AllowedOriginPatterns is a list of regular expressions, an origin
+	// matching any of them is allowed.
+	AllowedOriginPatterns []*regexp.Regexp
+
+	// AllowOriginFunc is a custom function to validate the origin. It is
+	// consulted when neither AllowedOrigins nor AllowedOriginPatterns match.
+	AllowOriginFunc func(r *http.Request, origin string) bool
+
+	// AllowedMethods restricts the methods the client is allowed to use with
+	// cross-domain requests. By default, all methods that are routed for the
+	// requested path are allowed.
+	AllowedMethods []string
+
+	// AllowedHeaders is a list of non-simple headers the client is allowed
+	// to use with cross-domain requests. "*" allows all headers.
+	AllowedHeaders []string
+
+	// ExposedHeaders is a list of response headers that are safe to expose
+	// to the client.
+	ExposedHeaders []string
+
+	// AllowCredentials indicates whether the request can include user
+	// credentials like cookies or HTTP authentication. It can't be combined
+	// with the "*" origin, the allowed origins must be listed.
+	AllowCredentials bool
+
+	// MaxAge indicates how long the results of a preflight request can be
+	// cached by the client.
+	MaxAge time.Duration
+
+	// OptionsPassthrough continues routing preflight requests to the OPTIONS
+	// handlers of the router, after the CORS headers have been set.
+	OptionsPassthrough bool
+}
+
+// corsMethods is the list of methods considered for preflight requests when
+// CORSOpts.AllowedMethods is not set.
+var corsMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodConnect, http.MethodTrace,
+}
+
+// CORS is a middleware that implements Cross-Origin Resource Sharing, see
+// https://fetch.spec.whatwg.org/#http-cors-protocol.
+//
+// Preflight requests are answered by CORS itself, before the router would
+// respond with a 405 Method Not Allowed for a path without an OPTIONS route.
+// The methods listed in Access-Control-Allow-Methods are looked up in the
+// routing tree of the root router for the requested path and host, and a
+// preflight request for any other method is refused.
+//
+//  r := chi.NewRouter()
+//  r.Use(middleware.CORS(middleware.CORSOpts{
+//    AllowedOrigins:   []string{"https://*.example.com"},
+//    AllowedHeaders:   []string{"Authorization", "Content-Type"},
+//    AllowCredentials: true,
+//    MaxAge:           time.Hour,
+//  }))
+func CORS(opts CORSOpts) func(next chi.Handler) chi.Handler {
+	c := &cors{
+		originPatterns:   opts.AllowedOriginPatterns,
+		allowOriginFn:    opts.AllowOriginFunc,
+		allowCredentials: opts.AllowCredentials,
+		passthrough:      opts.OptionsPassthrough,
+	}
+
+	for _, o := range opts.AllowedOrigins {
+		if o == "*" {
+			if opts.AllowCredentials {
+				panic("chi/middleware: CORS expects AllowedOrigins to list the origins allowed with credentials, instead of '*'")
+			}
+			c.allowAllOrigins = true
+			break
+		}
+		c.origins = append(c.origins, NewPattern(strings.ToLower(o)))
+	}
+	for _, m := range opts.AllowedMethods {
+		c.methods = append(c.methods, strings.ToUpper(m))
+	}
+	for _, h := range opts.AllowedHeaders {
+		if h == "*" {
+			c.allowAllHeaders = true
+			break
+		}
+		c.headers = append(c.headers, http.CanonicalHeaderKey(h))
+	}
+	if len(opts.ExposedHeaders) > 0 {
+		exposed := make([]string, len(opts.ExposedHeaders))
+		for i, h := range opts.ExposedHeaders {
+			exposed[i] = http.CanonicalHeaderKey(h)
+		}
+		c.exposedHeaders = strings.Join(exposed, ", ")
+	}
+	if opts.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(opts.MaxAge.Seconds()))
+	}
+
+	return func(next chi.Handler) chi.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				if err := c.preflight(w, r); err != nil || !c.passthrough {
+					return err
+				}
+				return next.ServeHTTP(w, r)
+			}
+
+			c.actual(w, r)
+			return next.ServeHTTP(w, r)
+		}
+		return chi.HandlerFunc(fn)
+	}
+}
+
+type cors struct {
+	allowAllOrigins  bool
+	origins          []Pattern
+	originPatterns   []*regexp.Regexp
+	allowOriginFn    func(r *http.Request, origin string) bool
+	methods          []string
+	allowAllHeaders  bool
+	headers          []string
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           string
+	passthrough      bool
+}
+
+// preflight answers a CORS preflight request.
+func (c *cors) preflight(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	origin := r.Header.Get("Origin")
+	if origin == "" || !c.isOriginAllowed(r, origin) {
+		return chi.Error{Code: http.StatusForbidden}
+	}
+
+	methods := c.allowedMethods(r)
+	if len(methods) == 0 {
+		return chi.Error{Code: http.StatusNotFound}
+	}
+	if !isMethodAllowed(methods, r.Header.Get("Access-Control-Request-Method")) {
+		return chi.Error{Code: http.StatusForbidden}
+	}
+
+	reqHeaders := parseHeaderList(r.Header.Get("Access-Control-Request-Headers"))
+	if !c.areHeadersAllowed(reqHeaders) {
+		return chi.Error{Code: http.StatusForbidden}
+	}
+
+	c.setOrigin(h, origin)
+	h.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if len(reqHeaders) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(reqHeaders, ", "))
+	}
+	if c.allowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if c.maxAge != "" {
+		h.Set("Access-Control-Max-Age", c.maxAge)
+	}
+
+	if !c.passthrough {
+		w.WriteHeader(http.StatusNoContent)
+	}
+	return nil
+}
+
+// actual sets the CORS headers of an actual cross-domain request.
+func (c *cors) actual(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	h.Add("Vary", "Origin")
+
+	origin := r.Header.Get("Origin")
+	if origin == "" || !c.isOriginAllowed(r, origin) {
+		return
+	}
+
+	c.setOrigin(h, origin)
+	if c.exposedHeaders != "" {
+		h.Set("Access-Control-Expose-Headers", c.exposedHeaders)
+	}
+	if c.allowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (c *cors) setOrigin(h http.Header, origin string) {
+	if c.allowAllOrigins {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+}
+
+func (c *cors) isOriginAllowed(r *http.Request, origin string) bool {
+	if c.allowAllOrigins {
+		return true
+	}
+	lorigin := strings.ToLower(origin)
+	for _, o := range c.origins {
+		if o.Match(lorigin) {
+			return true
+		}
+	}
+	for _, rx := range c.originPatterns {
+		if rx.MatchString(origin) {
+			return true
+		}
+	}
+	if c.allowOriginFn != nil {
+		return c.allowOriginFn(r, origin)
+	}
+	return false
+}
+
+func isMethodAllowed(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cors) areHeadersAllowed(reqHeaders []string) bool {
+	if c.allowAllHeaders {
+		return true
+	}
+	for _, rh := range reqHeaders {
+		allowed := false
+		for _, h := range c.headers {
+			if h == rh {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// allowedMethods returns the methods routed for the path of the preflight
+// request, restricted to CORSOpts.AllowedMethods if set.
+func (c *cors) allowedMethods(r *http.Request) []string {
+	candidates := c.methods
+	if len(candidates) == 0 {
+		candidates = corsMethods
+	}
+
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil || rctx.Routes == nil {
+		return candidates
+	}
+
+	// The routes are looked up from the root router, so the routing path is
+	// the request path, unless it was overridden before any sub-router.
+	routePath := rctx.RoutePath
+	if routePath == "" || len(rctx.RoutePatterns) > 0 {
+		if r.URL.RawPath != "" {
+			routePath = r.URL.RawPath
+		} else {
+			routePath = r.URL.Path
+		}
+	}
+
+	methods := []string{}
+	for _, m := range candidates {
+		tctx := chi.NewRouteContext()
+		tctx.RouteHost = r.Host
+		if rctx.Routes.Match(tctx, m, routePath) {
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+// parseHeaderList parses a comma separated list of header names.
+func parseHeaderList(s string) []string {
+	headers := []string{}
+	for _, h := range strings.Split(s, ",") {
+		h = strings.TrimSpace(h)
+		if h != "" {
+			headers = append(headers, http.CanonicalHeaderKey(h))
+		}
+	}
+	return headers
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/SirAiedail/chi"
+)
+
+func TestCORS(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(CORS(CORSOpts{
+		AllowedOrigins:        []string{"https://*.example.com"},
+		AllowedOriginPatterns: []*regexp.Regexp{regexp.MustCompile(`^https://app\d+\.test$`)},
+		AllowedHeaders:        []string{"Authorization", "content-type"},
+		ExposedHeaders:        []string{"x-total-count"},
+		AllowCredentials:      true,
+		MaxAge:                time.Hour,
+	}))
+	r.Get("/articles", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		w.Write([]byte("articles"))
+		return nil
+	})
+	r.Route("/articles/{id}", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) chi.HandlerError { return nil })
+		r.Put("/", func(w http.ResponseWriter, r *http.Request) chi.HandlerError { return nil })
+		r.Delete("/", func(w http.ResponseWriter, r *http.Request) chi.HandlerError { return nil })
+	})
+
+	server := httptest.NewServer(r.ToHTTPHandler())
+	defer server.Close()
+
+	preflight := func(path, origin, method, headers string) *http.Response {
+		req, _ := http.NewRequest("OPTIONS", server.URL+path, nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", method)
+		if headers != "" {
+			req.Header.Set("Access-Control-Request-Headers", headers)
+		}
+		res, err := http.DefaultClient.Do(req)
+		assertNoError(t, err)
+		res.Body.Close()
+		return res
+	}
+
+	res := preflight("/articles/5", "https://api.example.com", "PUT", "content-type, authorization")
+	assertEqual(t, http.StatusNoContent, res.StatusCode)
+	assertEqual(t, "https://api.example.com", res.Header.Get("Access-Control-Allow-Origin"))
+	assertEqual(t, "GET, PUT, DELETE", res.Header.Get("Access-Control-Allow-Methods"))
+	assertEqual(t, "Content-Type, Authorization", res.Header.Get("Access-Control-Allow-Headers"))
+	assertEqual(t, "true", res.Header.Get("Access-Control-Allow-Credentials"))
+	assertEqual(t, "3600", res.Header.Get("Access-Control-Max-Age"))
+
+	res = preflight("/articles", "https://app2.test", "GET", "")
+	assertEqual(t, http.StatusNoContent, res.StatusCode)
+	assertEqual(t, "GET", res.Header.Get("Access-Control-Allow-Methods"))
+
+	res = preflight("/articles", "https://app2.test", "PUT", "")
+	assertEqual(t, http.StatusForbidden, res.StatusCode)
+
+	res = preflight("/articles", "https://evil.test", "GET", "")
+	assertEqual(t, http.StatusForbidden, res.StatusCode)
+	assertEqual(t, "", res.Header.Get("Access-Control-Allow-Origin"))
+
+	res = preflight("/articles", "https://api.example.com", "GET", "X-Custom")
+	assertEqual(t, http.StatusForbidden, res.StatusCode)
+
+	res = preflight("/nothing", "https://api.example.com", "GET", "")
+	assertEqual(t, http.StatusNotFound, res.StatusCode)
+
+	req, _ := http.NewRequest("GET", server.URL+"/articles", nil)
+	req.Header.Set("Origin", "https://www.example.com")
+	res, err := http.DefaultClient.Do(req)
+	assertNoError(t, err)
+	res.Body.Close()
+	assertEqual(t, http.StatusOK, res.StatusCode)
+	assertEqual(t, "https://www.example.com", res.Header.Get("Access-Control-Allow-Origin"))
+	assertEqual(t, "X-Total-Count", res.Header.Get("Access-Control-Expose-Headers"))
+	assertEqual(t, "Origin", res.Header.Get("Vary"))
+}
+
+func TestCORSAllowAll(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(CORS(CORSOpts{
+		AllowedOrigins:     []string{"*"},
+		AllowedMethods:     []string{"get", "post"},
+		OptionsPassthrough: true,
+	}))
+	r.Options("/", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		w.Write([]byte("options"))
+		return nil
+	})
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) chi.HandlerError { return nil })
+	r.Post("/", func(w http.ResponseWriter, r *http.Request) chi.HandlerError { return nil })
+	r.Delete("/", func(w http.ResponseWriter, r *http.Request) chi.HandlerError { return nil })
+
+	server := httptest.NewServer(r.ToHTTPHandler())
+	defer server.Close()
+
+	req, _ := http.NewRequest("OPTIONS", server.URL+"/", nil)
+	req.Header.Set("Origin", "https://anywhere.test")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	res, err := http.DefaultClient.Do(req)
+	assertNoError(t, err)
+	res.Body.Close()
+	assertEqual(t, http.StatusOK, res.StatusCode)
+	assertEqual(t, "*", res.Header.Get("Access-Control-Allow-Origin"))
+	assertEqual(t, "GET, POST", res.Header.Get("Access-Control-Allow-Methods"))
+}
+
+func TestCORSSubRouters(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) chi.HandlerError { return nil }
+
+	r := chi.NewRouter()
+	r.Route("/api", func(r chi.Router) {
+		r.Use(CORS(CORSOpts{AllowedOrigins: []string{"https://app.test"}}))
+		r.Get("/users", h)
+		r.Post("/users", h)
+		r.When(chi.ContentType("application/json")).With(NoCache).Put("/users", h)
+	})
+	r.Host("admin.example.com", func(r chi.Router) {
+		r.Use(CORS(CORSOpts{AllowedOrigins: []string{"https://app.test"}}))
+		r.Delete("/users", h)
+	})
+
+	server := httptest.NewServer(r.ToHTTPHandler())
+	defer server.Close()
+
+	preflight := func(host, path, method string) *http.Response {
+		req, _ := http.NewRequest("OPTIONS", server.URL+path, nil)
+		req.Host = host
+		req.Header.Set("Origin", "https://app.test")
+		req.Header.Set("Access-Control-Request-Method", method)
+		res, err := http.DefaultClient.Do(req)
+		assertNoError(t, err)
+		res.Body.Close()
+		return res
+	}
+
+	res := preflight("example.com", "/api/users", "POST")
+	assertEqual(t, http.StatusNoContent, res.StatusCode)
+	assertEqual(t, "GET, POST, PUT", res.Header.Get("Access-Control-Allow-Methods"))
+
+	res = preflight("admin.example.com", "/users", "DELETE")
+	assertEqual(t, http.StatusNoContent, res.StatusCode)
+	assertEqual(t, "DELETE", res.Header.Get("Access-Control-Allow-Methods"))
+}
+
+func TestCORSCredentialsWithAllOrigins(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expecting CORS to panic for credentials with all origins")
+		}
+	}()
+	CORS(CORSOpts{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+}
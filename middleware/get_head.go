@@ -7,6 +7,9 @@ import (
 )
 
 // GetHead automatically route undefined HEAD requests to GET handlers.
+//
+// See also chi.Mux.AutoHead, which does the same in the router and discards
+// the response body of the GET handler.
 func GetHead(next chi.Handler) chi.Handler {
 	return chi.HandlerFunc(func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
 		if r.Method == "HEAD" {
@@ -22,6 +25,7 @@ func GetHead(next chi.Handler) chi.Handler {
 
 			// Temporary routing context to look-ahead before routing the request
 			tctx := chi.NewRouteContext()
+			tctx.RouteHost = r.Host
 
 			// Attempt to find a HEAD handler for the routing path, if not found, traverse
 			// the router as through its a GET route, but proceed with the request
@@ -27,8 +27,8 @@ var (
 // print in color, otherwise it will print in black and white. Logger prints a
 // request ID if one is provided.
 //
-// Alternatively, look at https://github.com/goware/httplog for a more in-depth
-// http logger with structured logging support.
+// For structured logging, use RequestLogger with a SlogFormatter. Alternatively,
+// look at https://github.com/goware/httplog for a more in-depth http logger.
 func Logger(next chi.Handler) chi.Handler {
 	return DefaultLogger(next)
 }
@@ -41,11 +41,13 @@ func RequestLogger(f LogFormatter) func(next chi.Handler) chi.Handler {
 			ww := NewWrapResponseWriter(w, r.ProtoMajor)
 
 			t1 := time.Now()
+			var err chi.HandlerError
 			defer func() {
-				entry.Write(ww.Status(), ww.BytesWritten(), ww.Header(), time.Since(t1), nil)
+				entry.Write(ww.Status(), ww.BytesWritten(), ww.Header(), time.Since(t1), err)
 			}()
 
-			return next.ServeHTTP(ww, WithLogEntry(r, entry))
+			err = next.ServeHTTP(ww, WithLogEntry(r, entry))
+			return err
 		}
 		return chi.HandlerFunc(fn)
 	}
@@ -59,6 +61,10 @@ type LogFormatter interface {
 
 // LogEntry records the final log when a request completes.
 // See defaultLogEntry for an example implementation.
+//
+// RequestLogger passes the HandlerError returned by the handler chain as
+// `extra`, or nil if there's none. As the error is handled after the chain
+// returns, `status` is 0 if the handler didn't write a response itself.
 type LogEntry interface {
 	Write(status, bytes int, header http.Header, elapsed time.Duration, extra interface{})
 	Panic(v interface{}, stack []byte)
@@ -0,0 +1,269 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SirAiedail/chi"
+)
+
+var (
+	// DefaultDurationBuckets are the upper bounds in seconds of the buckets
+	// of the request duration histogram.
+	DefaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+	// DefaultSizeBuckets are the upper bounds in bytes of the buckets of the
+	// response size histogram.
+	DefaultSizeBuckets = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+// MetricsOpts represents a set of request metrics options.
+type MetricsOpts struct {
+	// Namespace is prepended to the metric names, such as "myapp" for
+	// "myapp_http_requests_total".
+	Namespace string
+
+	// DurationBuckets defaults to DefaultDurationBuckets.
+	DurationBuckets []float64
+
+	// SizeBuckets defaults to DefaultSizeBuckets.
+	SizeBuckets []float64
+}
+
+// Metrics records the requests passing through its Handler middleware, and
+// exposes them in the OpenMetrics text format through ServeMetrics:
+//
+//   http_requests_total                  counter of the requests
+//   http_request_duration_seconds        histogram of the request durations
+//   http_response_size_bytes             histogram of the response body sizes
+//   http_requests_in_flight              gauge of the requests being served
+//
+// The metrics are labelled by the request method, the routing pattern of the
+// request as `route`, and the response status. The in-flight gauge is only
+// labelled by method, as the route isn't known before routing the request.
+//
+//   metrics := middleware.NewMetrics(middleware.MetricsOpts{})
+//   r.Use(metrics.Handler)
+//   r.Get("/metrics", metrics.ServeMetrics)
+type Metrics struct {
+	opts MetricsOpts
+
+	mu       sync.Mutex
+	requests map[metricLabels]*requestMetrics
+	inFlight map[string]int
+}
+
+type metricLabels struct {
+	method, route string
+	status        int
+}
+
+type requestMetrics struct {
+	count    uint64
+	duration histogram
+	size     histogram
+}
+
+// histogram counts observations into buckets by their upper bounds, with a
+// last bucket for the observations above all bounds.
+type histogram struct {
+	counts []uint64
+	sum    float64
+}
+
+func (h *histogram) observe(bounds []float64, v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(bounds)+1)
+	}
+	i := sort.SearchFloat64s(bounds, v)
+	h.counts[i]++
+	h.sum += v
+}
+
+// NewMetrics creates a new Metrics with the options `opts`.
+func NewMetrics(opts MetricsOpts) *Metrics {
+	if opts.DurationBuckets == nil {
+		opts.DurationBuckets = DefaultDurationBuckets
+	}
+	if opts.SizeBuckets == nil {
+		opts.SizeBuckets = DefaultSizeBuckets
+	}
+	if !sort.Float64sAreSorted(opts.DurationBuckets) || !sort.Float64sAreSorted(opts.SizeBuckets) {
+		panic("chi/middleware: Metrics expects buckets in increasing order")
+	}
+	if opts.Namespace != "" {
+		opts.Namespace += "_"
+	}
+
+	return &Metrics{
+		opts:     opts,
+		requests: make(map[metricLabels]*requestMetrics),
+		inFlight: make(map[string]int),
+	}
+}
+
+// Handler is a middleware that records the metrics of each request. The
+// status of a request is the status written by the handler, or the status
+// code of the HandlerError returned by the handler, which is written by an
+// error handler after this middleware returns. Methods other than the
+// standard HTTP methods are recorded as "OTHER", so that clients can't
+// create any number of series. Mount it with Use on the
+// top-level router, so that the routing pattern is complete once the
+// request has been served.
+func (m *Metrics) Handler(next chi.Handler) chi.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		method := metricMethod(r.Method)
+		m.mu.Lock()
+		m.inFlight[method]++
+		m.mu.Unlock()
+		defer func() {
+			m.mu.Lock()
+			if m.inFlight[method]--; m.inFlight[method] <= 0 {
+				delete(m.inFlight, method)
+			}
+			m.mu.Unlock()
+		}()
+
+		ww := NewWrapResponseWriter(w, r.ProtoMajor)
+		t1 := time.Now()
+		err := next.ServeHTTP(ww, r)
+		elapsed := time.Since(t1)
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+			if err != nil {
+				status = err.StatusCode()
+			}
+		}
+
+		var route string
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			route = rctx.RoutePattern()
+		}
+
+		m.observe(metricLabels{method, route, status}, elapsed, ww.BytesWritten())
+		return err
+	}
+	return chi.HandlerFunc(fn)
+}
+
+// metricMethod returns the method label of a request method.
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+func (m *Metrics) observe(labels metricLabels, elapsed time.Duration, size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rm := m.requests[labels]
+	if rm == nil {
+		rm = &requestMetrics{}
+		m.requests[labels] = rm
+	}
+	rm.count++
+	rm.duration.observe(m.opts.DurationBuckets, elapsed.Seconds())
+	rm.size.observe(m.opts.SizeBuckets, float64(size))
+}
+
+// ServeMetrics writes the recorded metrics in the OpenMetrics text format.
+func (m *Metrics) ServeMetrics(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+	var buf bytes.Buffer
+	m.WriteMetrics(&buf)
+
+	w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+	w.Write(buf.Bytes())
+	return nil
+}
+
+// WriteMetrics writes the recorded metrics in the OpenMetrics text format to
+// `buf`.
+func (m *Metrics) WriteMetrics(buf *bytes.Buffer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	labels := make([]metricLabels, 0, len(m.requests))
+	for l := range m.requests {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		a, b := labels[i], labels[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+
+	name := m.opts.Namespace + "http_requests"
+	fmt.Fprintf(buf, "# TYPE %s counter\n# HELP %s Number of HTTP requests.\n", name, name)
+	for _, l := range labels {
+		fmt.Fprintf(buf, "%s_total{%s} %d\n", name, l, m.requests[l].count)
+	}
+
+	name = m.opts.Namespace + "http_request_duration_seconds"
+	fmt.Fprintf(buf, "# TYPE %s histogram\n# UNIT %s seconds\n# HELP %s Duration of HTTP requests.\n", name, name, name)
+	for _, l := range labels {
+		writeHistogram(buf, name, l.String(), m.opts.DurationBuckets, &m.requests[l].duration)
+	}
+
+	name = m.opts.Namespace + "http_response_size_bytes"
+	fmt.Fprintf(buf, "# TYPE %s histogram\n# UNIT %s bytes\n# HELP %s Size of HTTP response bodies.\n", name, name, name)
+	for _, l := range labels {
+		writeHistogram(buf, name, l.String(), m.opts.SizeBuckets, &m.requests[l].size)
+	}
+
+	methods := make([]string, 0, len(m.inFlight))
+	for method := range m.inFlight {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	name = m.opts.Namespace + "http_requests_in_flight"
+	fmt.Fprintf(buf, "# TYPE %s gauge\n# HELP %s Number of HTTP requests being served.\n", name, name)
+	for _, method := range methods {
+		fmt.Fprintf(buf, "%s{method=%s} %d\n", name, quoteLabel(method), m.inFlight[method])
+	}
+
+	buf.WriteString("# EOF\n")
+}
+
+func writeHistogram(buf *bytes.Buffer, name, labels string, bounds []float64, h *histogram) {
+	var count uint64
+	for i, bound := range bounds {
+		count += h.counts[i]
+		fmt.Fprintf(buf, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatFloat(bound), count)
+	}
+	count += h.counts[len(bounds)]
+	fmt.Fprintf(buf, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, count)
+	fmt.Fprintf(buf, "%s_sum{%s} %s\n", name, labels, formatFloat(h.sum))
+	fmt.Fprintf(buf, "%s_count{%s} %d\n", name, labels, count)
+}
+
+func (l metricLabels) String() string {
+	return fmt.Sprintf("method=%s,route=%s,status=\"%d\"", quoteLabel(l.method), quoteLabel(l.route), l.status)
+}
+
+// quoteLabel returns the quoted label value `s`, with backslashes, double
+// quotes and line feeds escaped.
+func quoteLabel(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
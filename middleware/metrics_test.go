@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SirAiedail/chi"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics(MetricsOpts{Namespace: "test", DurationBuckets: []float64{60}, SizeBuckets: []float64{4, 1000}})
+
+	r := chi.NewRouter()
+	r.Use(metrics.Handler)
+	r.Route("/users", func(r chi.Router) {
+		r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+			w.Write([]byte("hello"))
+			return nil
+		})
+	})
+	r.Get("/teapot", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		return chi.Error{Code: http.StatusTeapot}
+	})
+	r.Get("/metrics", metrics.ServeMetrics)
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	testRequest(t, ts, "GET", "/users/1", nil)
+	testRequest(t, ts, "GET", "/users/2", nil)
+	testRequest(t, ts, "FOO", "/users/3", nil)
+	testRequest(t, ts, "BAR", "/users/4", nil)
+	if resp, _ := testRequest(t, ts, "GET", "/teapot", nil); resp.StatusCode != http.StatusTeapot {
+		t.Fatalf("expecting 418, got %d", resp.StatusCode)
+	}
+
+	resp, body := testRequest(t, ts, "GET", "/metrics", nil)
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/openmetrics-text") {
+		t.Fatalf("unexpected content type '%s'", resp.Header.Get("Content-Type"))
+	}
+
+	for _, line := range []string{
+		"# TYPE test_http_requests counter",
+		`test_http_requests_total{method="GET",route="/users/{id}",status="200"} 2`,
+		`test_http_requests_total{method="GET",route="/teapot",status="418"} 1`,
+		`test_http_request_duration_seconds_bucket{method="GET",route="/users/{id}",status="200",le="60"} 2`,
+		`test_http_request_duration_seconds_count{method="GET",route="/users/{id}",status="200"} 2`,
+		`test_http_response_size_bytes_bucket{method="GET",route="/users/{id}",status="200",le="4"} 0`,
+		`test_http_response_size_bytes_bucket{method="GET",route="/users/{id}",status="200",le="1000"} 2`,
+		`test_http_response_size_bytes_bucket{method="GET",route="/users/{id}",status="200",le="+Inf"} 2`,
+		`test_http_response_size_bytes_sum{method="GET",route="/users/{id}",status="200"} 10`,
+		`test_http_requests_in_flight{method="GET"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Fatalf("expecting line '%s' in:\n%s", line, body)
+		}
+	}
+	if strings.Contains(body, "FOO") || strings.Count(body, `test_http_requests_total{method="OTHER"`) != 1 {
+		t.Fatalf("expecting other methods to be recorded as OTHER in:\n%s", body)
+	}
+	if strings.Count(body, "test_http_requests_in_flight{") != 1 {
+		t.Fatalf("expecting only the in-flight GET request in:\n%s", body)
+	}
+	if !strings.HasSuffix(body, "# EOF\n") {
+		t.Fatalf("expecting '# EOF' at the end of:\n%s", body)
+	}
+}
+
+func TestQuoteLabel(t *testing.T) {
+	if q := quoteLabel("a\"b\\c\nd"); q != `"a\"b\\c\nd"` {
+		t.Fatalf("unexpected quoted label %s", q)
+	}
+}
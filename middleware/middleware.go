@@ -1,12 +1,16 @@
 package middleware
 
-import "net/http"
+import (
+	"net/http"
 
-// New will create a new middleware handler from a http.Handler.
-func New(h http.Handler) func(next http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			h.ServeHTTP(w, r)
+	"github.com/SirAiedail/chi"
+)
+
+// New will create a new middleware handler from a chi.Handler.
+func New(h chi.Handler) func(next chi.Handler) chi.Handler {
+	return func(next chi.Handler) chi.Handler {
+		return chi.HandlerFunc(func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+			return h.ServeHTTP(w, r)
 		})
 	}
 }
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/SirAiedail/chi"
+)
+
+var (
+	// NegotiatedCtxKey is the context.Context key to store the media type
+	// picked by Negotiate.
+	NegotiatedCtxKey = &contextKey{"Negotiated"}
+)
+
+// Negotiate is a middleware that picks the media type of the response from
+// `offers`, such as "application/json" or "application/vnd.app.v2+json",
+// by the request's Accept header. The picked offer is stored on the request
+// context, see GetNegotiatedType.
+//
+// The media ranges of the Accept header are weighted by their q-values, and
+// the most specific range matching an offer sets its weight. Offers with the
+// same weight are preferred in the order given, so the first offer is picked
+// for a request without an Accept header. Offers may have parameters, such as
+// "application/vnd.app+json; version=2", which a media range with parameters
+// needs to match.
+//
+// If none of the offers is acceptable, the request fails with a 406 Not
+// Acceptable.
+//
+//   r.With(middleware.Negotiate("application/vnd.app.v2+json", "application/json")).
+//     Get("/articles", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+//       switch middleware.GetNegotiatedType(r.Context()) {
+//       ...
+//     })
+func Negotiate(offers ...string) func(next chi.Handler) chi.Handler {
+	mediaTypes := make([]chi.MediaRange, len(offers))
+	for i, offer := range offers {
+		mr, ok := chi.ParseMediaRange(offer)
+		if !ok || mr.Type == "*" || mr.Subtype == "*" {
+			panic(fmt.Sprintf("chi/middleware: invalid media type offer '%s'", offer))
+		}
+		mediaTypes[i] = mr
+	}
+
+	return func(next chi.Handler) chi.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+			w.Header().Add("Vary", "Accept")
+
+			i := negotiate(r.Header.Get("Accept"), mediaTypes)
+			if i < 0 {
+				return chi.Error{Code: http.StatusNotAcceptable}
+			}
+
+			ctx := context.WithValue(r.Context(), NegotiatedCtxKey, offers[i])
+			return next.ServeHTTP(w, r.WithContext(ctx))
+		}
+		return chi.HandlerFunc(fn)
+	}
+}
+
+// GetNegotiatedType returns the media type offer picked by Negotiate, or an
+// empty string if the request didn't pass through Negotiate.
+func GetNegotiatedType(ctx context.Context) string {
+	mediaType, _ := ctx.Value(NegotiatedCtxKey).(string)
+	return mediaType
+}
+
+// Representation is a media type served by NegotiateHandler, such as a
+// version of an API's presentation of a resource.
+type Representation struct {
+	MediaType string
+	Handler   chi.Handler
+}
+
+// NegotiateHandler returns a handler that serves the request with the handler
+// of the representation picked by Negotiate from the media types of
+// `representations`, in their order of preference.
+func NegotiateHandler(representations ...Representation) chi.Handler {
+	offers := make([]string, len(representations))
+	handlers := make(map[string]chi.Handler, len(representations))
+	for i, rep := range representations {
+		offers[i] = rep.MediaType
+		handlers[rep.MediaType] = rep.Handler
+	}
+
+	fn := func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		return handlers[GetNegotiatedType(r.Context())].ServeHTTP(w, r)
+	}
+	return Negotiate(offers...)(chi.HandlerFunc(fn))
+}
+
+// negotiate returns the index of the offer with the highest weight by the
+// media ranges of `accept`, or -1 if no offer is acceptable.
+func negotiate(accept string, offers []chi.MediaRange) int {
+	if strings.TrimSpace(accept) == "" {
+		if len(offers) == 0 {
+			return -1
+		}
+		return 0
+	}
+
+	ranges := chi.ParseAccept(accept)
+	best, bestQ := -1, 0.0
+	for i, offer := range offers {
+		if q := chi.AcceptQuality(ranges, offer); q > bestQ {
+			best, bestQ = i, q
+		}
+	}
+	return best
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SirAiedail/chi"
+)
+
+func TestNegotiate(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Negotiate("application/json", "application/vnd.app.v2+json", "application/vnd.app+json; version=3", "text/html"))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		w.Write([]byte(GetNegotiatedType(r.Context())))
+		return nil
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	tests := []struct {
+		accept string
+		status int
+		body   string
+	}{
+		{"", 200, "application/json"},
+		{"*/*", 200, "application/json"},
+		{"*", 200, "application/json"},
+		{"text/html;q=0.5, *; q=.2", 200, "text/html"},
+		{"text/*", 200, "text/html"},
+		{"application/vnd.app.v2+json", 200, "application/vnd.app.v2+json"},
+		{"application/vnd.app+json; version=3", 200, "application/vnd.app+json; version=3"},
+		{"application/vnd.app+json; version=4", 406, "Not Acceptable\n"},
+		{"application/json;q=0.5, text/html", 200, "text/html"},
+		{"application/*;q=0.2, application/vnd.app.v2+json;q=0.9, */*;q=0.1", 200, "application/vnd.app.v2+json"},
+		{"*/*, application/json;q=0", 200, "application/vnd.app.v2+json"},
+		{"image/png, text/plain", 406, "Not Acceptable\n"},
+		{"text/html;q=0", 406, "Not Acceptable\n"},
+	}
+	for _, tt := range tests {
+		resp, body := testRequestWithAccept(t, ts, "/", tt.accept)
+		if resp.StatusCode != tt.status || body != tt.body {
+			t.Fatalf("Accept '%s': expecting %d with '%s', got %d with '%s'", tt.accept, tt.status, tt.body, resp.StatusCode, body)
+		}
+		if resp.Header.Get("Vary") != "Accept" {
+			t.Fatalf("Accept '%s': expecting Vary header", tt.accept)
+		}
+	}
+}
+
+func TestNegotiateHandler(t *testing.T) {
+	presenter := func(version string) chi.Handler {
+		return chi.HandlerFunc(func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+			w.Write([]byte(version))
+			return nil
+		})
+	}
+
+	r := chi.NewRouter()
+	r.Method("GET", "/articles", NegotiateHandler(
+		Representation{"application/vnd.app.v2+json", presenter("v2")},
+		Representation{"application/vnd.app.v1+json", presenter("v1")},
+	))
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	for accept, want := range map[string]string{
+		"":                            "v2",
+		"application/vnd.app.v1+json": "v1",
+		"application/vnd.app.v2+json;q=0.5, application/vnd.app.v1+json": "v1",
+	} {
+		if _, body := testRequestWithAccept(t, ts, "/articles", accept); body != want {
+			t.Fatalf("Accept '%s': expecting '%s', got '%s'", accept, want, body)
+		}
+	}
+}
+
+func testRequestWithAccept(t *testing.T, ts *httptest.Server, path, accept string) (*http.Response, string) {
+	req, err := http.NewRequest("GET", ts.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+		return nil, ""
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+		return nil, ""
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+		return nil, ""
+	}
+	defer resp.Body.Close()
+
+	return resp, string(respBody)
+}
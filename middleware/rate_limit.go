@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SirAiedail/chi"
+)
+
+var (
+	errRateLimited = errors.New("rate limit exceeded")
+)
+
+// RateLimitOpts represents a set of rate limiting options.
+type RateLimitOpts struct {
+	// Limit is the number of requests allowed per Window for each key.
+	Limit int
+
+	// Window is the duration of the sliding window.
+	Window time.Duration
+
+	// KeyFunc returns the key to count requests against, defaults to
+	// KeyByIP.
+	KeyFunc func(r *http.Request) (string, error)
+
+	// Store keeps the request counters, defaults to an in-memory store.
+	Store RateLimitStore
+}
+
+// RateLimitStore keeps the request counters of the rate limiter for fixed
+// windows, identified by their start time. Implementations must be safe for
+// concurrent use.
+type RateLimitStore interface {
+	// Increment increments the request count of `key` in the current window
+	// and returns the request counts of `key` in the current window,
+	// including this request, and in the previous window. It must be atomic,
+	// so that concurrent requests never see the same count.
+	Increment(key string, currWindow, prevWindow time.Time) (curr int, prev int, err error)
+}
+
+// RateLimit is a middleware that limits the number of requests per client IP
+// address to `limit` requests per `window`. It's based on a sliding window
+// that weighs the count of the previous window against the elapsed time of
+// the current window.
+//
+// Responses carry the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset
+// headers. Once the limit is reached, RateLimit sets the Retry-After header
+// and returns a 429 Too Many Requests error, which is handled by the router's
+// error handler.
+//
+// Use RealIP before RateLimit to count requests against the client address
+// forwarded by a reverse proxy. Note: unlike Throttle, which caps the number
+// of in-flight requests across all users, RateLimit limits each client
+// separately.
+func RateLimit(limit int, window time.Duration) func(next chi.Handler) chi.Handler {
+	return RateLimitWithOpts(RateLimitOpts{Limit: limit, Window: window, KeyFunc: KeyByIP})
+}
+
+// RateLimitByHeader is a middleware that limits the number of requests per
+// value of the request header `header`, such as an API key.
+func RateLimitByHeader(header string, limit int, window time.Duration) func(next chi.Handler) chi.Handler {
+	return RateLimitWithOpts(RateLimitOpts{Limit: limit, Window: window, KeyFunc: KeyByHeader(header)})
+}
+
+// RateLimitWithOpts is a middleware that limits the number of requests per key using passed RateLimitOpts.
+func RateLimitWithOpts(opts RateLimitOpts) func(next chi.Handler) chi.Handler {
+	if opts.Limit < 1 {
+		panic("chi/middleware: RateLimit expects limit > 0")
+	}
+	if opts.Window <= 0 {
+		panic("chi/middleware: RateLimit expects window > 0")
+	}
+	if opts.KeyFunc == nil {
+		opts.KeyFunc = KeyByIP
+	}
+	if opts.Store == nil {
+		opts.Store = NewRateLimitMemoryStore()
+	}
+
+	l := rateLimiter{
+		limit:       opts.Limit,
+		window:      opts.Window,
+		keyFn:       opts.KeyFunc,
+		store:       opts.Store,
+		nowFn:       time.Now,
+		limitHeader: strconv.Itoa(opts.Limit),
+	}
+
+	return func(next chi.Handler) chi.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+			key, err := l.keyFn(r)
+			if err != nil {
+				return chi.Error{Code: http.StatusInternalServerError, Err: err}
+			}
+			if err := l.take(w, key); err != nil {
+				return err
+			}
+			return next.ServeHTTP(w, r)
+		}
+		return chi.HandlerFunc(fn)
+	}
+}
+
+// KeyByIP returns the IP address of the client, as found in the request's
+// RemoteAddr.
+func KeyByIP(r *http.Request) (string, error) {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		// RemoteAddr was set by RealIP without a port
+		ip = r.RemoteAddr
+	}
+	return ip, nil
+}
+
+// KeyByHeader returns a key func that keys requests by the value of the
+// request header `header`. Requests without the header are keyed by their IP
+// address, see KeyByIP. The keys are prefixed with "header:" and "ip:", so a
+// header value can't take the quota of an IP address.
+func KeyByHeader(header string) func(r *http.Request) (string, error) {
+	return func(r *http.Request) (string, error) {
+		if key := r.Header.Get(header); key != "" {
+			return "header:" + key, nil
+		}
+		ip, err := KeyByIP(r)
+		return "ip:" + ip, err
+	}
+}
+
+// rateLimiter limits the number of requests per key in a sliding window.
+type rateLimiter struct {
+	limit       int
+	window      time.Duration
+	keyFn       func(r *http.Request) (string, error)
+	store       RateLimitStore
+	nowFn       func() time.Time
+	limitHeader string
+}
+
+// take counts a request against `key` and sets the rate limit headers,
+// returning an error once the limit is reached. Requests over the limit are
+// counted as well, so clients retrying too early stay limited.
+func (l rateLimiter) take(w http.ResponseWriter, key string) chi.HandlerError {
+	now := l.nowFn()
+	currWindow := now.Truncate(l.window)
+	prevWindow := currWindow.Add(-l.window)
+	reset := currWindow.Add(l.window).Sub(now)
+
+	curr, prev, err := l.store.Increment(key, currWindow, prevWindow)
+	if err != nil {
+		return chi.Error{Code: http.StatusInternalServerError, Err: err}
+	}
+
+	// Weigh the previous window by the part of it still inside the sliding window.
+	weight := float64(l.window-now.Sub(currWindow)) / float64(l.window)
+	rate := int(math.Floor(float64(prev)*weight)) + curr
+
+	h := w.Header()
+	h.Set("RateLimit-Limit", l.limitHeader)
+	h.Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(reset.Seconds()))))
+
+	if rate > l.limit {
+		h.Set("RateLimit-Remaining", "0")
+		h.Set("Retry-After", strconv.Itoa(int(math.Ceil(reset.Seconds()))))
+		return chi.Error{Code: http.StatusTooManyRequests, Err: errRateLimited}
+	}
+
+	h.Set("RateLimit-Remaining", strconv.Itoa(l.limit-rate))
+	return nil
+}
+
+// NewRateLimitMemoryStore returns a RateLimitStore that keeps the request
+// counters in memory. Counters of windows that are no longer needed are
+// discarded as time passes.
+func NewRateLimitMemoryStore() RateLimitStore {
+	return &rateLimitMemoryStore{counters: make(map[rateLimitCounterKey]int)}
+}
+
+type rateLimitCounterKey struct {
+	key    string
+	window int64
+}
+
+type rateLimitMemoryStore struct {
+	mu         sync.Mutex
+	counters   map[rateLimitCounterKey]int
+	currWindow int64
+}
+
+func (s *rateLimitMemoryStore) Increment(key string, currWindow, prevWindow time.Time) (int, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	window := currWindow.UnixNano()
+	if window > s.currWindow {
+		// A new window has started, so only the counters of the last window
+		// are still needed as the previous window.
+		for k := range s.counters {
+			if k.window < s.currWindow {
+				delete(s.counters, k)
+			}
+		}
+		s.currWindow = window
+	}
+
+	currKey := rateLimitCounterKey{key, window}
+	s.counters[currKey]++
+	return s.counters[currKey], s.counters[rateLimitCounterKey{key, prevWindow.UnixNano()}], nil
+}
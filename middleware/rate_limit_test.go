@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/SirAiedail/chi"
+)
+
+func TestRateLimit(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(RateLimitByHeader("X-API-Key", 3, time.Minute))
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		w.Write(testContent)
+		return nil
+	})
+
+	server := httptest.NewServer(r.ToHTTPHandler())
+	defer server.Close()
+
+	request := func(key string) *http.Response {
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		req.Header.Set("X-API-Key", key)
+		res, err := http.DefaultClient.Do(req)
+		assertNoError(t, err)
+		res.Body.Close()
+		return res
+	}
+
+	for i := 0; i < 3; i++ {
+		res := request("a")
+		assertEqual(t, http.StatusOK, res.StatusCode)
+		assertEqual(t, "3", res.Header.Get("RateLimit-Limit"))
+		assertEqual(t, []string{"2", "1", "0"}[i], res.Header.Get("RateLimit-Remaining"))
+	}
+
+	res := request("a")
+	assertEqual(t, http.StatusTooManyRequests, res.StatusCode)
+	assertEqual(t, "0", res.Header.Get("RateLimit-Remaining"))
+	assertEqual(t, true, res.Header.Get("Retry-After") != "")
+
+	res = request("b")
+	assertEqual(t, http.StatusOK, res.StatusCode)
+
+	// Requests without the key are limited by IP address
+	for i := 0; i < 3; i++ {
+		assertEqual(t, http.StatusOK, request("").StatusCode)
+	}
+	assertEqual(t, http.StatusTooManyRequests, request("").StatusCode)
+}
+
+func TestRateLimitConcurrent(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := rateLimiter{
+		limit:       10,
+		window:      time.Minute,
+		store:       NewRateLimitMemoryStore(),
+		nowFn:       func() time.Time { return now },
+		limitHeader: "10",
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if l.take(httptest.NewRecorder(), "key") == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if allowed > 10 {
+		t.Fatalf("expecting at most 10 requests to be allowed, got %d", allowed)
+	}
+}
+
+func TestRateLimitSlidingWindow(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := rateLimiter{
+		limit:       10,
+		window:      time.Minute,
+		store:       NewRateLimitMemoryStore(),
+		nowFn:       func() time.Time { return now },
+		limitHeader: "10",
+	}
+
+	for i := 0; i < 10; i++ {
+		assertEqual(t, nil, l.take(httptest.NewRecorder(), "key"))
+	}
+	assertEqual(t, http.StatusTooManyRequests, l.take(httptest.NewRecorder(), "key").StatusCode())
+
+	// Half way into the next window, half of the previous window still counts.
+	now = now.Add(time.Minute + 30*time.Second)
+	for i := 0; i < 5; i++ {
+		assertEqual(t, nil, l.take(httptest.NewRecorder(), "key"))
+	}
+	w := httptest.NewRecorder()
+	assertEqual(t, http.StatusTooManyRequests, l.take(w, "key").StatusCode())
+	assertEqual(t, "30", w.Header().Get("Retry-After"))
+
+	// Two windows later, nothing counts anymore.
+	now = now.Add(2 * time.Minute)
+	w = httptest.NewRecorder()
+	assertEqual(t, nil, l.take(w, "key"))
+	assertEqual(t, "9", w.Header().Get("RateLimit-Remaining"))
+}
+
+func TestKeyByIP(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	key, _ := KeyByIP(r)
+	assertEqual(t, "10.0.0.1", key)
+
+	r.RemoteAddr = "100.100.100.100"
+	key, _ = KeyByIP(r)
+	assertEqual(t, "100.100.100.100", key)
+}
+
+func TestKeyByHeader(t *testing.T) {
+	keyFn := KeyByHeader("X-API-Key")
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.5:1234"
+	ipKey, _ := keyFn(r)
+	assertEqual(t, "ip:10.0.0.5", ipKey)
+
+	// A header value can't collide with the key of an IP address
+	r.Header.Set("X-API-Key", "10.0.0.5")
+	headerKey, _ := keyFn(r)
+	assertEqual(t, "header:10.0.0.5", headerKey)
+}
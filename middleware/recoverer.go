@@ -16,31 +16,71 @@ import (
 )
 
 // Recoverer is a middleware that recovers from panics, logs the panic (and a
-// backtrace), and returns a HTTP 500 (Internal Server Error) status if
-// possible. Recoverer prints a request ID if one is provided.
+// backtrace), and returns a PanicError with a HTTP 500 (Internal Server Error)
+// status, which is handled by the router's error handler. Recoverer prints a
+// request ID if one is provided.
+//
+// Panics with http.ErrAbortHandler are not recovered, so that the response
+// to the client is aborted as intended.
 //
 // Alternatively, look at https://github.com/pressly/lg middleware pkgs.
 func Recoverer(next chi.Handler) chi.Handler {
-	fn := func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
-		err := next.ServeHTTP(w, r)
-		if err != nil {
-			return err
-		} else if rvr := recover(); rvr != nil && rvr != http.ErrAbortHandler {
+	fn := func(w http.ResponseWriter, r *http.Request) (err chi.HandlerError) {
+		defer func() {
+			rvr := recover()
+			if rvr == nil {
+				return
+			}
+			if rvr == http.ErrAbortHandler {
+				// we don't recover http.ErrAbortHandler so the response
+				// to the client is aborted, this should not be logged
+				panic(rvr)
+			}
+
+			stack := debug.Stack()
 			logEntry := GetLogEntry(r)
 			if logEntry != nil {
-				logEntry.Panic(rvr, debug.Stack())
+				logEntry.Panic(rvr, stack)
 			} else {
 				PrintPrettyStack(rvr)
 			}
-			return chi.Error{Code: http.StatusInternalServerError}
-		} else {
-			return nil
-		}
+
+			err = PanicError{Value: rvr, Stack: stack}
+		}()
+
+		return next.ServeHTTP(w, r)
 	}
 
 	return chi.HandlerFunc(fn)
 }
 
+// PanicError is the HandlerError returned by Recoverer for a recovered panic.
+type PanicError struct {
+	// Value is the value passed to panic().
+	Value interface{}
+
+	// Stack is the stack trace of the goroutine at the time of recovery.
+	Stack []byte
+}
+
+// StatusCode always returns 500 Internal Server Error.
+func (e PanicError) StatusCode() int {
+	return http.StatusInternalServerError
+}
+
+// Error returns the status text of 500 Internal Server Error, as error
+// handlers write it into the response body. The panic value isn't shown to
+// clients; it's available through Value.
+func (e PanicError) Error() string {
+	return http.StatusText(http.StatusInternalServerError)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
 func PrintPrettyStack(rvr interface{}) {
 	debugStack := debug.Stack()
 	s := prettyStack{}
@@ -73,7 +113,7 @@ func (s prettyStack) parse(debugStack []byte, rvr interface{}) ([]byte, error) {
 	// locate panic line, as we may have nested panics
 	for i := len(stack) - 1; i > 0; i-- {
 		lines = append(lines, stack[i])
-		if strings.HasPrefix(stack[i], "panic(0x") {
+		if strings.HasPrefix(stack[i], "panic(") && len(lines) >= 2 {
 			lines = lines[0 : len(lines)-2] // remove boilerplate
 			break
 		}
@@ -128,12 +168,18 @@ func (s prettyStack) decorateFuncCallLine(line string, useColor bool, num int) (
 	idx = strings.LastIndex(pkg, string(os.PathSeparator))
 	if idx < 0 {
 		idx = strings.Index(pkg, ".")
+		if idx < 0 {
+			return "", errors.New("not a func call line")
+		}
 		method = pkg[idx:]
 		pkg = pkg[0:idx]
 	} else {
 		method = pkg[idx+1:]
 		pkg = pkg[0 : idx+1]
 		idx = strings.Index(method, ".")
+		if idx < 0 {
+			return "", errors.New("not a func call line")
+		}
 		pkg += method[0:idx]
 		method = method[idx:]
 	}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SirAiedail/chi"
+)
+
+type panicLogFormatter struct {
+	panics []interface{}
+}
+
+func (f *panicLogFormatter) NewLogEntry(r *http.Request) LogEntry {
+	return &panicLogEntry{f}
+}
+
+type panicLogEntry struct {
+	*panicLogFormatter
+}
+
+func (e *panicLogEntry) Write(status, bytes int, header http.Header, elapsed time.Duration, extra interface{}) {
+}
+
+func (e *panicLogEntry) Panic(v interface{}, stack []byte) {
+	e.panics = append(e.panics, v)
+}
+
+func TestRecoverer(t *testing.T) {
+	errBoom := errors.New("boom")
+	formatter := &panicLogFormatter{}
+
+	var handled chi.HandlerError
+
+	r := chi.NewRouter()
+	r.Use(RequestLogger(formatter))
+	r.Use(Recoverer)
+	r.Error(func(err chi.HandlerError, w http.ResponseWriter, r *http.Request) {
+		handled = err
+		w.WriteHeader(err.StatusCode())
+	})
+	r.Get("/error", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		panic(errBoom)
+	})
+	r.Get("/value", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		panic("oops")
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	res, _ := testRequest(t, ts, "GET", "/error", nil)
+	assertEqual(t, http.StatusInternalServerError, res.StatusCode)
+
+	var perr PanicError
+	if !errors.As(handled, &perr) {
+		t.Fatalf("expecting a PanicError, got %T", handled)
+	}
+	assertEqual(t, errBoom, perr.Value)
+	assertEqual(t, true, len(perr.Stack) > 0)
+	assertEqual(t, true, errors.Is(handled, errBoom))
+
+	res, _ = testRequest(t, ts, "GET", "/value", nil)
+	assertEqual(t, http.StatusInternalServerError, res.StatusCode)
+	assertEqual(t, "Internal Server Error", handled.Error())
+	assertEqual(t, nil, errors.Unwrap(handled))
+
+	assertEqual(t, []interface{}{errBoom, "oops"}, formatter.panics)
+}
+
+func TestRecovererWithoutLogEntry(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Recoverer)
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		panic("oops")
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	res, body := testRequest(t, ts, "GET", "/", nil)
+	assertEqual(t, http.StatusInternalServerError, res.StatusCode)
+	assertEqual(t, "Internal Server Error\n", body)
+}
+
+func TestPrettyStack(t *testing.T) {
+	stack := []byte(`goroutine 1 [running]:
+runtime/debug.Stack()
+	/usr/local/go/src/runtime/debug/stack.go:24 +0x5e
+panic({0x6b4f20?, 0x7c1e60?})
+	/usr/local/go/src/runtime/panic.go:770 +0x132
+main.main()
+	/tmp/main.go:5 +0x25
+`)
+	out, err := prettyStack{}.parse(stack, "oops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out, []byte("main.go")) || bytes.Contains(out, []byte("runtime/debug")) {
+		t.Fatalf("unexpected pretty stack:\n%s", out)
+	}
+}
+
+func TestRecovererAbortHandler(t *testing.T) {
+	defer func() {
+		if rvr := recover(); rvr != http.ErrAbortHandler {
+			t.Fatalf("expecting http.ErrAbortHandler to be re-panicked, got %v", rvr)
+		}
+	}()
+
+	h := Recoverer(chi.HandlerFunc(func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		panic(http.ErrAbortHandler)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
@@ -70,3 +70,39 @@ func TestRequestID(t *testing.T) {
 		}
 	}
 }
+
+func TestRequestIDErrorHandler(t *testing.T) {
+	auth := func(next chi.Handler) chi.Handler {
+		return chi.HandlerFunc(func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+			if r.Header.Get("Authorization") == "" {
+				return chi.Error{Code: http.StatusUnauthorized}
+			}
+			return next.ServeHTTP(w, r)
+		})
+	}
+
+	r := chi.NewRouter()
+	r.Use(RequestID)
+	r.Use(auth)
+	r.Error(func(err chi.HandlerError, w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(err.StatusCode())
+		w.Write([]byte(fmt.Sprintf("%d %s", err.StatusCode(), GetReqID(r.Context()))))
+	})
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		return chi.Error{Code: http.StatusNotFound}
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	req, _ := http.NewRequest("GET", ts.URL+"/", nil)
+	req.Header.Set("X-Request-Id", "req-1")
+	if _, body := testClientRequest(t, req); body != "401 req-1" {
+		t.Fatalf("expecting the request ID in the error handler of a middleware error, got '%s'", body)
+	}
+
+	req.Header.Set("Authorization", "yes")
+	if _, body := testClientRequest(t, req); body != "404 req-1" {
+		t.Fatalf("expecting the request ID in the error handler of an endpoint error, got '%s'", body)
+	}
+}
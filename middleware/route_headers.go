@@ -3,6 +3,8 @@ package middleware
 import (
 	"net/http"
 	"strings"
+
+	"github.com/SirAiedail/chi"
 )
 
 // RouteHeaders is a neat little header-based router that allows you to direct
@@ -44,13 +46,16 @@ import (
 //   })).
 //   Handler)
 //
+// To choose the endpoint handler by a request header instead, see
+// chi.Mux.When and chi.HeaderEquals.
+//
 func RouteHeaders() HeaderRouter {
 	return HeaderRouter{}
 }
 
 type HeaderRouter map[string][]HeaderRoute
 
-func (hr HeaderRouter) Route(header string, match string, middlewareHandler func(next http.Handler) http.Handler) HeaderRouter {
+func (hr HeaderRouter) Route(header string, match string, middlewareHandler func(next chi.Handler) chi.Handler) HeaderRouter {
 	header = strings.ToLower(header)
 	k := hr[header]
 	if k == nil {
@@ -60,7 +65,7 @@ func (hr HeaderRouter) Route(header string, match string, middlewareHandler func
 	return hr
 }
 
-func (hr HeaderRouter) RouteAny(header string, match []string, middlewareHandler func(next http.Handler) http.Handler) HeaderRouter {
+func (hr HeaderRouter) RouteAny(header string, match []string, middlewareHandler func(next chi.Handler) chi.Handler) HeaderRouter {
 	header = strings.ToLower(header)
 	k := hr[header]
 	if k == nil {
@@ -74,16 +79,16 @@ func (hr HeaderRouter) RouteAny(header string, match []string, middlewareHandler
 	return hr
 }
 
-func (hr HeaderRouter) RouteDefault(handler func(next http.Handler) http.Handler) HeaderRouter {
+func (hr HeaderRouter) RouteDefault(handler func(next chi.Handler) chi.Handler) HeaderRouter {
 	hr["*"] = []HeaderRoute{{Middleware: handler}}
 	return hr
 }
 
-func (hr HeaderRouter) Handler(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+func (hr HeaderRouter) Handler(next chi.Handler) chi.Handler {
+	return chi.HandlerFunc(func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
 		if len(hr) == 0 {
 			// skip if no routes set
-			next.ServeHTTP(w, r)
+			return next.ServeHTTP(w, r)
 		}
 
 		// find first matching header route, and continue
@@ -95,8 +100,7 @@ func (hr HeaderRouter) Handler(next http.Handler) http.Handler {
 			headerValue = strings.ToLower(headerValue)
 			for _, matcher := range matchers {
 				if matcher.IsMatch(headerValue) {
-					matcher.Middleware(next).ServeHTTP(w, r)
-					return
+					return matcher.Middleware(next).ServeHTTP(w, r)
 				}
 			}
 		}
@@ -104,17 +108,16 @@ func (hr HeaderRouter) Handler(next http.Handler) http.Handler {
 		// if no match, check for "*" default route
 		matcher, ok := hr["*"]
 		if !ok || matcher[0].Middleware == nil {
-			next.ServeHTTP(w, r)
-			return
+			return next.ServeHTTP(w, r)
 		}
-		matcher[0].Middleware(next).ServeHTTP(w, r)
+		return matcher[0].Middleware(next).ServeHTTP(w, r)
 	})
 }
 
 type HeaderRoute struct {
 	MatchAny   []Pattern
 	MatchOne   Pattern
-	Middleware func(next http.Handler) http.Handler
+	Middleware func(next chi.Handler) chi.Handler
 }
 
 func (r HeaderRoute) IsMatch(value string) bool {
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SirAiedail/chi"
+)
+
+func TestRouteHeaders(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(RouteHeaders().
+		Route("X-Role", "admin", SetHeader("X-Routed", "admin")).
+		Route("X-Role", "guest*", AllowContentType("application/json")).
+		RouteDefault(SetHeader("X-Routed", "default")).
+		Handler)
+	r.Post("/", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		w.Write([]byte("ok"))
+		return nil
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	tests := []struct {
+		role   string
+		status int
+		routed string
+	}{
+		{"admin", http.StatusOK, "admin"},
+		{"guest-1", http.StatusUnsupportedMediaType, ""},
+		{"", http.StatusOK, "default"},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("POST", ts.URL+"/", strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "text/plain")
+		if tt.role != "" {
+			req.Header.Set("X-Role", tt.role)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assertNoError(t, err)
+		resp.Body.Close()
+		assertEqual(t, tt.status, resp.StatusCode)
+		assertEqual(t, tt.routed, resp.Header.Get("X-Routed"))
+	}
+}
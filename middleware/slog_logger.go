@@ -0,0 +1,148 @@
+//go:build go1.21
+// +build go1.21
+
+package middleware
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/SirAiedail/chi"
+)
+
+// SlogFormatter is a LogFormatter emitting a structured record for each
+// request through log/slog, for use with RequestLogger:
+//
+//   r.Use(middleware.RequestLogger(&middleware.SlogFormatter{
+//     Logger: slog.New(slog.NewJSONHandler(os.Stdout, nil)),
+//   }))
+//
+// The record holds the request method, route pattern, URL params, request
+// ID, remote IP, response status and bytes, the duration and the error
+// returned by the handler chain. Its level is Error for a 5xx status, Warn
+// for a 4xx status and Info otherwise. Handlers can add attributes to the
+// record of their request with LogAttrs.
+type SlogFormatter struct {
+	// Logger defaults to slog.Default().
+	Logger *slog.Logger
+
+	// Message of the records, defaults to "request".
+	Message string
+}
+
+// NewLogEntry creates a new SlogLogEntry for the request.
+func (f *SlogFormatter) NewLogEntry(r *http.Request) LogEntry {
+	return &SlogLogEntry{formatter: f, request: r}
+}
+
+// SlogLogEntry is the LogEntry of a request created by SlogFormatter.
+type SlogLogEntry struct {
+	formatter *SlogFormatter
+	request   *http.Request
+
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// Add adds attributes to the record of the request.
+func (e *SlogLogEntry) Add(attrs ...slog.Attr) {
+	e.mu.Lock()
+	e.attrs = append(e.attrs, attrs...)
+	e.mu.Unlock()
+}
+
+// LogAttrs adds attributes to the record of the request, if it's logged by
+// a RequestLogger with a SlogFormatter.
+func LogAttrs(r *http.Request, attrs ...slog.Attr) {
+	if entry, ok := GetLogEntry(r).(*SlogLogEntry); ok {
+		entry.Add(attrs...)
+	}
+}
+
+func (e *SlogLogEntry) Write(status, bytes int, header http.Header, elapsed time.Duration, extra interface{}) {
+	err, _ := extra.(chi.HandlerError)
+	if status == 0 {
+		status = http.StatusOK
+		if err != nil {
+			status = err.StatusCode()
+		}
+	}
+
+	level := slog.LevelInfo
+	switch {
+	case status >= 500:
+		level = slog.LevelError
+	case status >= 400:
+		level = slog.LevelWarn
+	}
+
+	attrs := e.requestAttrs()
+	attrs = append(attrs,
+		slog.Int("status", status),
+		slog.Int("bytes", bytes),
+		slog.Duration("duration", elapsed),
+	)
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	e.log(level, attrs)
+}
+
+func (e *SlogLogEntry) Panic(v interface{}, stack []byte) {
+	attrs := e.requestAttrs()
+	attrs = append(attrs,
+		slog.Any("panic", v),
+		slog.String("stack", string(stack)),
+	)
+	e.log(slog.LevelError, attrs)
+}
+
+// requestAttrs returns the attributes of the request, followed by the
+// attributes added by the handlers.
+func (e *SlogLogEntry) requestAttrs() []slog.Attr {
+	r := e.request
+	attrs := []slog.Attr{slog.String("method", r.Method)}
+
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		attrs = append(attrs, slog.String("route", rctx.RoutePattern()))
+		if len(rctx.URLParams.Keys) > 0 {
+			params := make([]interface{}, len(rctx.URLParams.Keys))
+			for i, key := range rctx.URLParams.Keys {
+				params[i] = slog.String(key, rctx.URLParams.Values[i])
+			}
+			attrs = append(attrs, slog.Group("params", params...))
+		}
+	} else {
+		attrs = append(attrs, slog.String("path", r.URL.Path))
+	}
+
+	if reqID := GetReqID(r.Context()); reqID != "" {
+		attrs = append(attrs, slog.String("request_id", reqID))
+	}
+
+	remoteIP := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remoteIP); err == nil {
+		remoteIP = host
+	}
+	attrs = append(attrs, slog.String("remote_ip", remoteIP))
+
+	e.mu.Lock()
+	attrs = append(attrs, e.attrs...)
+	e.mu.Unlock()
+	return attrs
+}
+
+func (e *SlogLogEntry) log(level slog.Level, attrs []slog.Attr) {
+	logger := e.formatter.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	msg := e.formatter.Message
+	if msg == "" {
+		msg = "request"
+	}
+	logger.LogAttrs(e.request.Context(), level, msg, attrs...)
+}
@@ -0,0 +1,63 @@
+//go:build go1.21
+// +build go1.21
+
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SirAiedail/chi"
+)
+
+func TestSlogFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	r := chi.NewRouter()
+	r.Use(RequestID)
+	r.Use(RequestLogger(&SlogFormatter{Logger: logger}))
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		LogAttrs(r, slog.String("user", "jane"))
+		return chi.Error{Code: http.StatusForbidden}
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	if resp, _ := testRequest(t, ts, "GET", "/users/42", nil); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expecting 403, got %d", resp.StatusCode)
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expecting a JSON record, got '%s': %v", buf.String(), err)
+	}
+
+	expected := map[string]interface{}{
+		"level":     "WARN",
+		"msg":       "request",
+		"method":    "GET",
+		"route":     "/users/{id}",
+		"remote_ip": "127.0.0.1",
+		"status":    float64(403),
+		"bytes":     float64(0),
+		"error":     "Forbidden",
+		"user":      "jane",
+	}
+	for key, value := range expected {
+		if record[key] != value {
+			t.Fatalf("expecting %s=%v, got %v in %s", key, value, record[key], buf.String())
+		}
+	}
+	if params, _ := record["params"].(map[string]interface{}); params["id"] != "42" {
+		t.Fatalf("expecting params.id=42 in %s", buf.String())
+	}
+	if record["request_id"] == "" || record["request_id"] == nil || record["duration"] == nil {
+		t.Fatalf("expecting request_id and duration in %s", buf.String())
+	}
+}
@@ -81,12 +81,23 @@ func ThrottleWithOpts(opts ThrottleOpts) func(chi.Handler) chi.Handler {
 				}
 
 			case btok := <-t.backlogTokens:
-				timer := time.NewTimer(t.backlogTimeout)
-
 				defer func() {
 					t.backlogTokens <- btok
 				}()
 
+				// Serve right away with an available token, before a zero
+				// backlog timeout can reject the request
+				select {
+				case tok := <-t.tokens:
+					defer func() {
+						t.tokens <- tok
+					}()
+					return next.ServeHTTP(w, r)
+				default:
+				}
+
+				timer := time.NewTimer(t.backlogTimeout)
+
 				select {
 				case <-timer.C:
 					t.setRetryAfterHeaderIfNeeded(w, false)
@@ -108,7 +119,6 @@ func ThrottleWithOpts(opts ThrottleOpts) func(chi.Handler) chi.Handler {
 					}()
 					return next.ServeHTTP(w, r)
 				}
-				return nil
 
 			default:
 				t.setRetryAfterHeaderIfNeeded(w, false)
@@ -1,55 +1,190 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/SirAiedail/chi"
 )
 
-// Timeout is a middleware that cancels ctx after a given timeout and return
-// a 504 Gateway Timeout error to the client.
+// TimeoutOpts represents a set of timeout options.
+type TimeoutOpts struct {
+	// Timeout is the maximum duration for a handler to complete.
+	Timeout time.Duration
+
+	// StatusCode is the status code of the error returned when the timeout
+	// is reached, defaults to 504 Gateway Timeout.
+	StatusCode int
+
+	// Passthrough reports whether a request should bypass the response
+	// buffering, for example for streaming routes. These requests still get
+	// a context with a deadline, but it is up to the handler to honour it.
+	Passthrough func(r *http.Request) bool
+}
+
+// Timeout is a middleware that cancels ctx after a given timeout and returns
+// a 504 Gateway Timeout error, which is handled by the router's error handler.
 //
-// It's required that you select the ctx.Done() channel to check for the signal
-// if the context has reached its deadline and return, otherwise the timeout
-// signal will be just ignored.
+// Like http.TimeoutHandler, the handler is run on its own goroutine and its
+// response is buffered until it completes. If the timeout is reached first,
+// the buffered response is discarded and any further writes by the handler
+// fail with http.ErrHandlerTimeout. Handlers should still select on the
+// ctx.Done() channel to stop doing work that is no longer needed.
 //
 // ie. a route/handler may look like:
 //
-//  r.Get("/long", func(w http.ResponseWriter, r *http.Request) {
+//  r.Get("/long", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
 // 	 ctx := r.Context()
 // 	 processTime := time.Duration(rand.Intn(4)+1) * time.Second
 //
 // 	 select {
 // 	 case <-ctx.Done():
-// 	 	return
+// 	 	return nil
 //
 // 	 case <-time.After(processTime):
 // 	 	 // The above channel simulates some hard work.
 // 	 }
 //
 // 	 w.Write([]byte("done"))
+// 	 return nil
 //  })
 //
+// As the response is buffered, Timeout doesn't support streaming responses.
+// Use TimeoutWithOpts with a Passthrough func for streaming routes.
 func Timeout(timeout time.Duration) func(next chi.Handler) chi.Handler {
+	return TimeoutWithOpts(TimeoutOpts{Timeout: timeout})
+}
+
+// TimeoutWithOpts is a middleware that enforces a timeout using passed TimeoutOpts.
+func TimeoutWithOpts(opts TimeoutOpts) func(next chi.Handler) chi.Handler {
+	if opts.Timeout <= 0 {
+		panic("chi/middleware: Timeout expects timeout > 0")
+	}
+	if opts.StatusCode == 0 {
+		opts.StatusCode = http.StatusGatewayTimeout
+	}
+
 	return func(next chi.Handler) chi.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
-			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			ctx, cancel := context.WithTimeout(r.Context(), opts.Timeout)
+			defer cancel()
 
-			r = r.WithContext(ctx)
-			err := next.ServeHTTP(w, r)
-			if err != nil {
-				return err
+			if opts.Passthrough != nil && opts.Passthrough(r) {
+				return next.ServeHTTP(w, r.WithContext(ctx))
+			}
+
+			// The handler gets its own copy of the routing context, as it may
+			// still be running after this request is done and the routing
+			// context has been reused.
+			rctx := chi.RouteContext(ctx)
+			var tctx *chi.Context
+			if rctx != nil {
+				tctx = rctx.Clone()
+				ctx = context.WithValue(ctx, chi.RouteCtxKey, tctx)
 			}
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{h: make(http.Header)}
+			done := make(chan chi.HandlerError, 1)
+			panicChan := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicChan <- p
+					}
+				}()
+				done <- next.ServeHTTP(tw, r)
+			}()
+
+			select {
+			case p := <-panicChan:
+				panic(p)
+
+			case err := <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				if rctx != nil {
+					*rctx = *tctx
+				}
+				if err == nil && ctx.Err() == context.DeadlineExceeded {
+					return chi.Error{Code: opts.StatusCode}
+				}
+
+				dst := w.Header()
+				for k, vv := range tw.h {
+					dst[k] = vv
+				}
+				if err != nil {
+					return err
+				}
 
-			cancel()
-			if ctx.Err() == context.DeadlineExceeded {
-				return chi.Error{Code: http.StatusGatewayTimeout}
-			} else {
+				if !tw.wroteHeader {
+					tw.code = http.StatusOK
+				}
+				w.WriteHeader(tw.code)
+				w.Write(tw.wbuf.Bytes())
 				return nil
+
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				tw.timedOut = true
+				if ctx.Err() == context.DeadlineExceeded {
+					return chi.Error{Code: opts.StatusCode}
+				}
+				return chi.Error{Code: http.StatusServiceUnavailable}
 			}
 		}
 		return chi.HandlerFunc(fn)
 	}
 }
+
+// timeoutWriter buffers the response of a handler run by the Timeout
+// middleware, and discards any writes after the timeout was reached.
+type timeoutWriter struct {
+	h    http.Header
+	wbuf bytes.Buffer
+
+	mu          sync.Mutex
+	timedOut    bool
+	wroteHeader bool
+	code        int
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.wbuf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	if tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	tw.code = code
+}
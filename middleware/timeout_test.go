@@ -74,7 +74,7 @@ func TestRouteTimeout(t *testing.T) {
 func TestCustomTimeoutError(t *testing.T) {
 	r := chi.NewRouter()
 
-	r.Use(Timeout(time.Second * 1))
+	r.Use(TimeoutWithOpts(TimeoutOpts{Timeout: time.Second * 1, StatusCode: http.StatusServiceUnavailable}))
 
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
 		ctx := r.Context()
@@ -101,7 +101,83 @@ func TestCustomTimeoutError(t *testing.T) {
 
 	res, err := client.Get(server.URL)
 	assertNoError(t, err)
-	assertEqual(t, http.StatusInternalServerError, res.StatusCode)
+	assertEqual(t, http.StatusServiceUnavailable, res.StatusCode)
+}
+
+func TestHandlerErrorBeforeTimeout(t *testing.T) {
+	r := chi.NewRouter()
+
+	r.Use(Timeout(time.Second * 1))
+
+	r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		w.Header().Set("X-Id", chi.URLParam(r, "id"))
+		w.Write([]byte("partial"))
+		return chi.Error{Code: http.StatusConflict}
+	})
+
+	server := httptest.NewServer(r.ToHTTPHandler())
+	defer server.Close()
+
+	res, body := testRequest(t, server, "GET", "/5", nil)
+	assertEqual(t, http.StatusConflict, res.StatusCode)
+	assertEqual(t, "5", res.Header.Get("X-Id"))
+	assertEqual(t, "Conflict\n", body)
+}
+
+func TestTimeoutIgnoredContext(t *testing.T) {
+	r := chi.NewRouter()
+
+	r.Use(Timeout(time.Millisecond * 100))
+
+	lateWrite := make(chan error, 1)
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		// ignores the context entirely
+		time.Sleep(time.Millisecond * 300)
+		w.Header().Set("X-Late", "yes")
+		_, err := w.Write(testContent)
+		lateWrite <- err
+		return nil
+	})
+
+	server := httptest.NewServer(r.ToHTTPHandler())
+	defer server.Close()
+
+	start := time.Now()
+	res, body := testRequest(t, server, "GET", "/", nil)
+	assertEqual(t, http.StatusGatewayTimeout, res.StatusCode)
+	assertEqual(t, "Gateway Timeout\n", body)
+	assertEqual(t, "", res.Header.Get("X-Late"))
+	assertEqual(t, true, time.Since(start) < time.Millisecond*300)
+	assertEqual(t, http.ErrHandlerTimeout, <-lateWrite)
+}
+
+func TestTimeoutPassthrough(t *testing.T) {
+	r := chi.NewRouter()
+
+	r.Use(TimeoutWithOpts(TimeoutOpts{
+		Timeout: time.Second * 1,
+		Passthrough: func(r *http.Request) bool {
+			return r.URL.Path == "/stream"
+		},
+	}))
+
+	r.Get("/stream", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("expecting a http.Flusher")
+		}
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("expecting a context deadline")
+		}
+		w.Write(testContent)
+		return nil
+	})
+
+	server := httptest.NewServer(r.ToHTTPHandler())
+	defer server.Close()
+
+	res, body := testRequest(t, server, "GET", "/stream", nil)
+	assertEqual(t, http.StatusOK, res.StatusCode)
+	assertEqual(t, string(testContent), body)
 }
 
 func TestClientTimeout(t *testing.T) {
@@ -0,0 +1,249 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SirAiedail/chi"
+)
+
+var (
+	// TraceCtxKey is the context.Context key to store the W3C trace context
+	// of a request.
+	TraceCtxKey = &contextKey{"TraceContext"}
+)
+
+// TraceContext is the W3C trace context of a request, propagated by the
+// `traceparent` and `tracestate` headers. See https://www.w3.org/TR/trace-context/.
+type TraceContext struct {
+	// TraceID identifies the trace across services.
+	TraceID [16]byte
+
+	// SpanID identifies the current span of the request, and ParentID the
+	// span of the caller, or is zero if the request started the trace.
+	SpanID   [8]byte
+	ParentID [8]byte
+
+	// Flags are the trace flags, such as the sampled flag 0x01.
+	Flags byte
+
+	// State is the vendor-specific trace state of the `tracestate` header.
+	State string
+}
+
+// Sampled reports whether the caller may have recorded the trace.
+func (tc TraceContext) Sampled() bool {
+	return tc.Flags&0x01 != 0
+}
+
+// TraceParent returns the `traceparent` header value of the trace context,
+// with the current span as the parent.
+func (tc TraceContext) TraceParent() string {
+	var b [55]byte
+	copy(b[:], "00-")
+	hex.Encode(b[3:35], tc.TraceID[:])
+	b[35] = '-'
+	hex.Encode(b[36:52], tc.SpanID[:])
+	b[52] = '-'
+	hex.Encode(b[53:55], []byte{tc.Flags})
+	return string(b[:])
+}
+
+// Inject sets the `traceparent` and `tracestate` headers of an outgoing
+// request to propagate the trace context.
+func (tc TraceContext) Inject(h http.Header) {
+	h.Set("traceparent", tc.TraceParent())
+	if tc.State != "" {
+		h.Set("tracestate", tc.State)
+	} else {
+		h.Del("tracestate")
+	}
+}
+
+// ParseTraceContext parses the `traceparent` and `tracestate` headers of an
+// incoming request into a trace context with a new span. It returns false if
+// the `traceparent` header is missing or invalid.
+func ParseTraceContext(h http.Header) (TraceContext, bool) {
+	var tc TraceContext
+
+	// version-traceid-parentid-flags, with fields added by later versions
+	// after the flags
+	tp := strings.TrimSpace(h.Get("traceparent"))
+	if len(tp) < 55 || tp[2] != '-' || tp[35] != '-' || tp[52] != '-' {
+		return tc, false
+	}
+	var version [1]byte
+	if !decodeHex(version[:], tp[0:2]) || version[0] == 0xff || (version[0] == 0 && len(tp) != 55) ||
+		(len(tp) > 55 && tp[55] != '-') {
+		return tc, false
+	}
+	var flags [1]byte
+	if !decodeHex(tc.TraceID[:], tp[3:35]) || !decodeHex(tc.ParentID[:], tp[36:52]) || !decodeHex(flags[:], tp[53:55]) {
+		return tc, false
+	}
+	if tc.TraceID == [16]byte{} || tc.ParentID == [8]byte{} {
+		return tc, false
+	}
+	tc.Flags = flags[0]
+
+	// The trace state is kept as it is, up to its maximum of 32 members
+	var members []string
+	for _, v := range h["Tracestate"] {
+		for _, m := range strings.Split(v, ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				members = append(members, m)
+			}
+		}
+	}
+	if len(members) <= 32 {
+		tc.State = strings.Join(members, ",")
+	}
+
+	rand.Read(tc.SpanID[:])
+	return tc, true
+}
+
+// NewTraceContext returns the trace context of an incoming request with the
+// headers `h`, see ParseTraceContext, or starts a new sampled trace.
+func NewTraceContext(h http.Header) TraceContext {
+	if tc, ok := ParseTraceContext(h); ok {
+		return tc
+	}
+	tc := TraceContext{Flags: 0x01}
+	rand.Read(tc.TraceID[:])
+	rand.Read(tc.SpanID[:])
+	return tc
+}
+
+// decodeHex decodes the lowercase hex string `s` into `dst`.
+func decodeHex(dst []byte, s string) bool {
+	if strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+// GetTraceContext returns the trace context of the request context, set by
+// TraceParent or a SpanRecorder.
+func GetTraceContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(TraceCtxKey).(TraceContext)
+	return tc, ok
+}
+
+// TraceParent is a middleware that propagates the W3C trace context of the
+// request, continuing the trace of the `traceparent` header or starting a
+// new one. The trace context is stored on the request context, see
+// GetTraceContext, to be injected into outgoing requests. A trace context
+// already set by the chi.Tracer of the router is kept.
+func TraceParent(next chi.Handler) chi.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		if _, ok := GetTraceContext(r.Context()); ok {
+			return next.ServeHTTP(w, r)
+		}
+		ctx := context.WithValue(r.Context(), TraceCtxKey, NewTraceContext(r.Header))
+		return next.ServeHTTP(w, r.WithContext(ctx))
+	}
+	return chi.HandlerFunc(fn)
+}
+
+// RecordedSpan is a span recorded by a SpanRecorder.
+type RecordedSpan struct {
+	Kind     chi.SpanKind
+	Name     string
+	TraceID  [16]byte
+	SpanID   [8]byte
+	ParentID [8]byte
+	Start    time.Time
+	End      time.Time
+
+	// Err is the error returned by the span, and Status its status code or
+	// 0 without an error.
+	Err    chi.HandlerError
+	Status int
+}
+
+// SpanRecorder is a chi.Tracer recording the spans of the requests in
+// memory, for tests and debugging without an external collector. The spans
+// continue the W3C trace context of the request, and the trace context of
+// the request context is set to the current span, so it's propagated to
+// outgoing requests. The routing span is renamed to the method and routing
+// pattern of the request once it ends, such as "GET /users/{id}".
+//
+//   recorder := &middleware.SpanRecorder{}
+//   r.Tracer(recorder)
+type SpanRecorder struct {
+	mu    sync.Mutex
+	spans []RecordedSpan
+}
+
+var recordingSpanCtxKey = &contextKey{"RecordingSpan"}
+
+// Start implements chi.Tracer.
+func (sr *SpanRecorder) Start(r *http.Request, kind chi.SpanKind, name string) *http.Request {
+	ctx := r.Context()
+	parent, _ := ctx.Value(recordingSpanCtxKey).(*RecordedSpan)
+
+	var tc TraceContext
+	if parent != nil {
+		tc, _ = GetTraceContext(ctx)
+		tc.ParentID = tc.SpanID
+		rand.Read(tc.SpanID[:])
+	} else if tc, _ = GetTraceContext(ctx); tc.TraceID == [16]byte{} {
+		tc = NewTraceContext(r.Header)
+	}
+
+	span := &RecordedSpan{
+		Kind:     kind,
+		Name:     name,
+		TraceID:  tc.TraceID,
+		SpanID:   tc.SpanID,
+		ParentID: tc.ParentID,
+		Start:    time.Now(),
+	}
+
+	ctx = context.WithValue(ctx, TraceCtxKey, tc)
+	ctx = context.WithValue(ctx, recordingSpanCtxKey, span)
+	return r.WithContext(ctx)
+}
+
+// End implements chi.Tracer.
+func (sr *SpanRecorder) End(r *http.Request, kind chi.SpanKind, err chi.HandlerError) {
+	span, ok := r.Context().Value(recordingSpanCtxKey).(*RecordedSpan)
+	if !ok {
+		return
+	}
+	span.End = time.Now()
+	span.Err = err
+	if err != nil {
+		span.Status = err.StatusCode()
+	}
+	if kind == chi.SpanRouting {
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			span.Name = r.Method + " " + rctx.RoutePattern()
+		}
+	}
+
+	sr.mu.Lock()
+	sr.spans = append(sr.spans, *span)
+	sr.mu.Unlock()
+}
+
+// Spans returns the recorded spans, in the order they ended.
+func (sr *SpanRecorder) Spans() []RecordedSpan {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return append([]RecordedSpan(nil), sr.spans...)
+}
+
+// Reset discards the recorded spans.
+func (sr *SpanRecorder) Reset() {
+	sr.mu.Lock()
+	sr.spans = nil
+	sr.mu.Unlock()
+}
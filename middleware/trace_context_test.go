@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SirAiedail/chi"
+)
+
+func TestParseTraceContext(t *testing.T) {
+	tests := []struct {
+		traceparent string
+		ok          bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future", true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		h.Set("traceparent", tt.traceparent)
+		if _, ok := ParseTraceContext(h); ok != tt.ok {
+			t.Fatalf("%s: expecting %v, got %v", tt.traceparent, tt.ok, ok)
+		}
+	}
+
+	h := http.Header{}
+	h.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.Add("tracestate", "rojo=00f067aa0ba902b7")
+	h.Add("tracestate", "congo=t61rcWkgMzE")
+	tc, _ := ParseTraceContext(h)
+	if !tc.Sampled() || hex.EncodeToString(tc.ParentID[:]) != "00f067aa0ba902b7" || tc.State != "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE" {
+		t.Fatalf("unexpected trace context %+v", tc)
+	}
+
+	out := http.Header{}
+	tc.Inject(out)
+	expected := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + hex.EncodeToString(tc.SpanID[:]) + "-01"
+	if out.Get("traceparent") != expected || out.Get("tracestate") != tc.State {
+		t.Fatalf("expecting traceparent '%s', got %v", expected, out)
+	}
+}
+
+func TestTraceParent(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(TraceParent)
+	r.Get("/", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		tc, _ := GetTraceContext(r.Context())
+		w.Write([]byte(hex.EncodeToString(tc.TraceID[:])))
+		return nil
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	req, _ := http.NewRequest("GET", ts.URL+"/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if _, body := testClientRequest(t, req); body != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("expecting the trace ID of the request, got '%s'", body)
+	}
+
+	req, _ = http.NewRequest("GET", ts.URL+"/", nil)
+	if _, body := testClientRequest(t, req); len(body) != 32 || body == "00000000000000000000000000000000" {
+		t.Fatalf("expecting a new trace ID, got '%s'", body)
+	}
+}
+
+func TestSpanRecorder(t *testing.T) {
+	recorder := &SpanRecorder{}
+
+	r := chi.NewRouter()
+	r.Tracer(recorder)
+	r.Use(TraceParent)
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+		tc, _ := GetTraceContext(r.Context())
+		w.Write([]byte(tc.TraceParent()))
+		return chi.Error{Code: http.StatusNotFound}
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	req, _ := http.NewRequest("GET", ts.URL+"/users/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	_, body := testClientRequest(t, req)
+
+	spans := recorder.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("expecting 3 spans, got %+v", spans)
+	}
+	handler, mw, routing := spans[0], spans[1], spans[2]
+	if routing.Kind != chi.SpanRouting || routing.Name != "GET /users/{id}" || routing.Status != http.StatusNotFound ||
+		hex.EncodeToString(routing.ParentID[:]) != "00f067aa0ba902b7" {
+		t.Fatalf("unexpected routing span %+v", routing)
+	}
+	if mw.Kind != chi.SpanMiddleware || mw.Name != "middleware.TraceParent" || mw.ParentID != routing.SpanID {
+		t.Fatalf("unexpected middleware span %+v", mw)
+	}
+	if handler.Kind != chi.SpanHandler || handler.Name != "/users/{id}" || handler.ParentID != mw.SpanID ||
+		handler.TraceID != routing.TraceID {
+		t.Fatalf("unexpected handler span %+v", handler)
+	}
+
+	expected := "00-4bf92f3577b34da6a3ce929d0e0e4736-" + hex.EncodeToString(handler.SpanID[:]) + "-01"
+	if body[:len(expected)] != expected {
+		t.Fatalf("expecting the handler span to be propagated as '%s', got '%s'", expected, body)
+	}
+
+	recorder.Reset()
+	if len(recorder.Spans()) != 0 {
+		t.Fatal("expecting no spans after Reset")
+	}
+}
+
+func testClientRequest(t *testing.T, req *http.Request) (*http.Response, string) {
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+		return nil, ""
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+		return nil, ""
+	}
+	return resp, string(respBody)
+}
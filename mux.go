@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -46,6 +47,39 @@ type Mux struct {
 
 	// Custom method to handle errors that occur in middleware and request handlers
 	errorHandler ErrorHandlerFunc
+
+	// Respond to OPTIONS requests for routed paths without an Options handler
+	autoOptions bool
+
+	// Route HEAD requests to GET handlers if there's no Head handler
+	autoHead bool
+
+	// Policy for request paths that aren't in their canonical form
+	pathPolicy PathPolicy
+
+	// Matching of static pattern segments against request paths in another case
+	caseSensitivity CaseSensitivity
+
+	// Name of the routes registered on an inline mux created by Named
+	routeName string
+
+	// Routing patterns of the named routes, see URLFor
+	routeNames map[string]string
+
+	// Host routing tree, see Host
+	hosts *node
+
+	// Conditions of the routes registered on an inline mux created by When
+	conditions []RouteCondition
+
+	// Metadata of the routes registered on an inline mux created by WithMeta
+	meta Meta
+
+	// Tracer of the requests served by the Mux as the root router
+	tracer Tracer
+
+	// Computed mux handler with the middlewares in spans, see routeHandler
+	traced tracedChain
 }
 
 // NewMux returns a newly initialized Mux object that implements the Router
@@ -62,18 +96,34 @@ func NewMux() *Mux {
 // Mux interoperable with the standard library. It uses a sync.Pool to get and
 // reuse routing contexts for each request.
 func (mx *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) HandlerError {
+	return mx.serveHTTP(w, r, nil)
+}
+
+// serveHTTP serves the request through the Mux. If `errorHandler` is set, it
+// handles any error returned by the handler chain before the routing context
+// is put back into the sync pool. A Mux mounted as a sub-router handles
+// errors with its own error handler, if one is set.
+func (mx *Mux) serveHTTP(w http.ResponseWriter, r *http.Request, errorHandler ErrorHandlerFunc) HandlerError {
 	// Ensure the mux has some routes defined on the mux
 	if mx.handler == nil {
-		return Error{
+		err := Error{
 			Code: 500,
 			Err:  errors.New("chi: attempting to route to a mux with no handlers"),
 		}
+		if errorHandler != nil {
+			errorHandler(err, w, r)
+			return nil
+		}
+		return err
 	}
 
 	// Check if a routing context already exists from a parent router.
 	rctx, _ := r.Context().Value(RouteCtxKey).(*Context)
 	if rctx != nil {
-		return mx.handler.ServeHTTP(w, r)
+		if errorHandler == nil {
+			errorHandler = mx.errorHandler
+		}
+		return mx.handleError(mx.routeHandler(rctx).ServeHTTP(w, r), w, r, errorHandler)
 	}
 
 	// Fetch a RouteContext object from the sync pool, and call the computed
@@ -83,12 +133,45 @@ func (mx *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) HandlerError {
 	rctx = mx.pool.Get().(*Context)
 	rctx.Reset()
 	rctx.Routes = mx
+	rctx.tracer = mx.tracer
 
 	// NOTE: r.WithContext() causes 2 allocations and context.WithValue() causes 1 allocation
 	r = r.WithContext(context.WithValue(r.Context(), RouteCtxKey, rctx))
 	// Serve the request and once its done, put the request context back in the sync pool
 	defer mx.pool.Put(rctx)
-	return mx.handler.ServeHTTP(w, r)
+	err := mx.handleError(rctx.trace(w, r, SpanRouting, r.Method, mx.routeHandler(rctx)), w, r, errorHandler)
+	if err != nil && errorHandler != nil {
+		// The error was passed on by the root error handler, there is no
+		// parent left to handle it.
+		return mx.handleError(err, w, r, DefaultErrorHandler)
+	}
+	return err
+}
+
+// handleError calls `errorHandler` for a non-nil `err` with the request as it
+// was when the error occurred. Without an `errorHandler`, `err` is returned
+// as is. If the error handler passes the error on with PassError, the passed
+// error is returned for the parent router to handle.
+func (mx *Mux) handleError(err HandlerError, w http.ResponseWriter, r *http.Request, errorHandler ErrorHandlerFunc) HandlerError {
+	if err == nil || errorHandler == nil {
+		return err
+	}
+
+	rctx := RouteContext(r.Context())
+	if rctx == nil {
+		errorHandler(err, w, r)
+		return nil
+	}
+
+	er := rctx.errorRequestFor(err, r)
+	rctx.passedError = nil
+	errorHandler(err, w, er)
+
+	err, rctx.passedError = rctx.passedError, nil
+	if err == nil {
+		rctx.errorRequest, rctx.requestErr = nil, nil
+	}
+	return err
 }
 
 // Use appends a middleware handler to the Mux middleware stack.
@@ -208,6 +291,8 @@ func (mx *Mux) NotFound(handlerFn HandlerFunc) {
 
 // MethodNotAllowed sets a custom HandlerFunc for routing paths where the
 // method is unresolved. The default handler returns a 405 with an empty body.
+// The Allow header is set before the handler is called, and the methods routed
+// for the path are available through Context.AllowedMethods.
 func (mx *Mux) MethodNotAllowed(handlerFn HandlerFunc) {
 	// Build MethodNotAllowed handler chain
 	m := mx
@@ -226,6 +311,69 @@ func (mx *Mux) MethodNotAllowed(handlerFn HandlerFunc) {
 	})
 }
 
+// AutoOptions enables automatic responses to OPTIONS requests for routing
+// paths without an explicit Options handler. The response is a 204 with the
+// Allow header listing the methods routed for the path. The setting applies to
+// the sub-routers mounted on the Mux as well.
+func (mx *Mux) AutoOptions(enabled bool) {
+	m := mx
+	if mx.inline && mx.parent != nil {
+		m = mx.parent
+	}
+
+	m.autoOptions = enabled
+	m.updateSubRoutes(func(subMux *Mux) {
+		subMux.AutoOptions(enabled)
+	})
+}
+
+// AutoHead enables routing HEAD requests to the GET handler of a routing path
+// without an explicit Head handler. The response body written by the GET
+// handler is discarded, but counted for the Content-Length header. The
+// setting applies to the sub-routers mounted on the Mux as well.
+func (mx *Mux) AutoHead(enabled bool) {
+	m := mx
+	if mx.inline && mx.parent != nil {
+		m = mx.parent
+	}
+
+	m.autoHead = enabled
+	m.updateSubRoutes(func(subMux *Mux) {
+		subMux.AutoHead(enabled)
+	})
+}
+
+// PathPolicy sets how the Mux treats request paths with a trailing slash,
+// duplicate slashes or "." and ".." segments. The policy is applied to the
+// routing path before looking up the route. Redirect policies only redirect
+// if the request path doesn't match a route as is, but its canonical form
+// does. Sub-routers, including the routers of Host, route the path as
+// normalized by their parent.
+func (mx *Mux) PathPolicy(policy PathPolicy) {
+	m := mx
+	if mx.inline && mx.parent != nil {
+		m = mx.parent
+	}
+	m.pathPolicy = policy
+}
+
+// CaseSensitivity sets how the Mux matches static segments of routing
+// patterns against request paths in another case, such as /API/Users for the
+// pattern /api/users. A static segment in the exact case takes precedence.
+// The setting applies to the sub-routers mounted on the Mux as well, so a
+// redirect covers the whole request path.
+func (mx *Mux) CaseSensitivity(cs CaseSensitivity) {
+	m := mx
+	if mx.inline && mx.parent != nil {
+		m = mx.parent
+	}
+
+	m.caseSensitivity = cs
+	m.updateSubRoutes(func(subMux *Mux) {
+		subMux.CaseSensitivity(cs)
+	})
+}
+
 // With adds inline middlewares for an endpoint handler.
 func (mx *Mux) With(middlewares ...func(Handler) Handler) Router {
 	// Similarly as in handle(), we must build the mux handler once additional
@@ -245,11 +393,54 @@ func (mx *Mux) With(middlewares ...func(Handler) Handler) Router {
 	im := &Mux{
 		pool: mx.pool, inline: true, parent: mx, tree: mx.tree, middlewares: mws,
 		notFoundHandler: mx.notFoundHandler, methodNotAllowedHandler: mx.methodNotAllowedHandler,
+		conditions: mx.conditions, meta: mx.meta,
 	}
 
 	return im
 }
 
+// Named returns an inline-Router that registers its route under `name`, so
+// its URL can be built with URLFor. Names must be unique across the routing
+// tree, which is checked when a route is named and when a sub-router is
+// attached with Mount, Route or Host, but the inline-Router can register
+// several methods of the same pattern under its name, for example:
+//
+//  r.Named("article").Get("/articles/{articleID}", getArticle)
+//  url, err := r.URLFor("article", "articleID", "42") // "/articles/42"
+func (mx *Mux) Named(name string) Router {
+	im := mx.With().(*Mux)
+	im.routeName = name
+	return im
+}
+
+// When returns an inline-Router that registers its routes with additional
+// `conditions` on the request. Several routes can share the same method and
+// pattern with different conditions, and are matched in the order they're
+// registered. A route registered without conditions is the fallback of the
+// conditional routes. If the request satisfies no route of the path, it's
+// answered with a 415 or 406 for an unsatisfied ContentType or Accepts
+// condition, and by the NotFound handler otherwise.
+//
+//  r.When(chi.ContentType("application/json")).Post("/articles", createArticleJSON)
+//  r.When(chi.ContentType("multipart/form-data")).Post("/articles", createArticleForm)
+func (mx *Mux) When(conditions ...RouteCondition) Router {
+	im := mx.With().(*Mux)
+	im.conditions = append(im.conditions[:len(im.conditions):len(im.conditions)], conditions...)
+	return im
+}
+
+// WithMeta returns an inline-Router that registers its routes with the
+// metadata `meta`, merged with the metadata of its parent inline-Router.
+// The metadata of the route matching a request is available through
+// RouteMeta, and to tooling through Routes and WalkMeta.
+//
+//  r.WithMeta(chi.Meta{"scope": "articles:write"}).Post("/articles", createArticle)
+func (mx *Mux) WithMeta(meta Meta) Router {
+	im := mx.With().(*Mux)
+	im.meta = im.meta.merge(meta)
+	return im
+}
+
 // Group creates a new inline-Mux with a fresh middleware stack. It's useful
 // for a group of handlers along the same routing path that use an additional
 // set of middlewares. See _examples/.
@@ -281,6 +472,10 @@ func (mx *Mux) Route(pattern string, fn func(r Router)) Router {
 // routing at the `handler`, which in most cases is another chi.Router. As a result,
 // if you define two Mount() routes on the exact same pattern the mount will panic.
 func (mx *Mux) Mount(pattern string, handler Handler) {
+	if len(mx.conditions) > 0 {
+		panic(fmt.Sprintf("chi: attempting to Mount() a handler with route conditions on '%s'", pattern))
+	}
+
 	// Provide runtime safety for ensuring a pattern isn't mounted on an existing
 	// routing pattern.
 	if mx.tree.findPattern(pattern+"*") || mx.tree.findPattern(pattern+"/*") {
@@ -288,12 +483,9 @@ func (mx *Mux) Mount(pattern string, handler Handler) {
 	}
 
 	// Assign sub-Router's with the parent not found & method not allowed handler if not specified.
-	subr, ok := handler.(*Mux)
-	if ok && subr.notFoundHandler == nil && mx.notFoundHandler != nil {
-		subr.NotFound(mx.notFoundHandler)
-	}
-	if ok && subr.methodNotAllowedHandler == nil && mx.methodNotAllowedHandler != nil {
-		subr.MethodNotAllowed(mx.methodNotAllowedHandler)
+	if subr, ok := handler.(*Mux); ok {
+		mx.checkRouteNames(pattern, subr)
+		mx.configureSubRouter(subr)
 	}
 
 	// Wrap the sub-router in a handlerFunc to scope the request path for routing.
@@ -303,14 +495,17 @@ func (mx *Mux) Mount(pattern string, handler Handler) {
 		return handler.ServeHTTP(w, r)
 	})
 
+	subroutes, _ := handler.(Routes)
+
 	if pattern == "" || pattern[len(pattern)-1] != '/' {
-		mx.handle(mALL|mSTUB, pattern, mountHandler)
-		mx.handle(mALL|mSTUB, pattern+"/", mountHandler)
+		// The stub nodes also reference the subroutes, so Match can continue
+		// the search in the sub-router for requests to the mount point itself.
+		mx.handle(mALL|mSTUB, pattern, mountHandler).subroutes = subroutes
+		mx.handle(mALL|mSTUB, pattern+"/", mountHandler).subroutes = subroutes
 		pattern += "/"
 	}
 
 	method := mALL
-	subroutes, _ := handler.(Routes)
 	if subroutes != nil {
 		method |= mSTUB
 	}
@@ -318,13 +513,19 @@ func (mx *Mux) Mount(pattern string, handler Handler) {
 
 	if subroutes != nil {
 		n.subroutes = subroutes
+		mx.nameRoute(pattern + "*")
 	}
 }
 
 // Routes returns a slice of routing information from the tree,
-// useful for traversing available routes of a router.
+// useful for traversing available routes of a router. The sub-routers
+// added by Host are listed last, with the host pattern as their pattern.
 func (mx *Mux) Routes() []Route {
-	return mx.tree.routes()
+	routes := mx.tree.routes()
+	if mx.hosts != nil {
+		routes = append(routes, mx.hosts.routes()...)
+	}
+	return routes
 }
 
 // Middlewares returns a slice of middleware handler functions.
@@ -336,6 +537,9 @@ func (mx *Mux) Middlewares() Middlewares {
 // It's similar to routing a http request, but without executing the handler
 // thereafter.
 //
+// Routes registered with Host are only matched if the RouteHost of `rctx`
+// is set to the host of the request.
+//
 // Note: the *Context state is updated during execution, so manage
 // the state carefully or make a NewRouteContext().
 func (mx *Mux) Match(rctx *Context, method, path string) bool {
@@ -344,6 +548,15 @@ func (mx *Mux) Match(rctx *Context, method, path string) bool {
 		return false
 	}
 
+	path = mx.pathPolicy.normalize(path)
+	if mx.hosts != nil && rctx.RouteHost != "" {
+		if subr, _ := mx.findHost(rctx, rctx.RouteHost); subr != nil {
+			return subr.Match(rctx, method, path)
+		}
+	}
+
+	rctx.autoHead = mx.autoHead
+	rctx.caseInsensitive = mx.caseSensitivity != CaseSensitive
 	node, _, h := mx.tree.FindRoute(rctx, m, path)
 
 	if node != nil && node.subroutes != nil {
@@ -354,6 +567,14 @@ func (mx *Mux) Match(rctx *Context, method, path string) bool {
 	return h != nil
 }
 
+// matchPath reports whether `path` matches a route for the method and host of
+// the request routed with `rctx`, see Match.
+func (mx *Mux) matchPath(rctx *Context, r *http.Request, path string) bool {
+	tctx := NewRouteContext()
+	tctx.RouteHost = routeHost(rctx, r)
+	return mx.Match(tctx, rctx.RouteMethod, path)
+}
+
 // NotFoundHandler returns the default Mux 404 responder whenever a route
 // cannot be found.
 func (mx *Mux) NotFoundHandler() HandlerFunc {
@@ -372,12 +593,38 @@ func (mx *Mux) MethodNotAllowedHandler() HandlerFunc {
 	return methodNotAllowedHandler
 }
 
+// ErrorHandler returns the error handler set with Error, or
+// DefaultErrorHandler.
+func (mx *Mux) ErrorHandler() ErrorHandlerFunc {
+	if mx.errorHandler != nil {
+		return mx.errorHandler
+	}
+	return DefaultErrorHandler
+}
+
 // Error allows providing a function to handle errors that occurred
 // in middleware or request handlers.
-// Since this handler has to be wrapped around all others to catch
-// all errors bubbling up, it has no access to context values set
-// by the router, with Mux.Value or in a custom handler.
+//
+// The handler is called before the routing context is released, with the
+// request as it was passed to the innermost route handler or router that
+// returned the error. This gives it access to the matched RoutePattern(),
+// URL params and context values set by middlewares, such as the request ID.
+// An error returned by a middleware itself comes with the request as it was
+// passed to the middleware stack of its router.
+//
+// On a sub-router attached with Mount or Route, the handler only handles
+// errors from that sub-router's subtree. Errors of a sub-router without an
+// error handler, or errors passed on with PassError, continue to the parent
+// router. Setting an error handler on an inline router created with With or
+// Group sets it on its parent.
+//
+// See ProblemErrorHandler for a handler that renders RFC 7807 problem
+// details.
 func (mx *Mux) Error(h ErrorHandlerFunc) {
+	if mx.inline && mx.parent != nil {
+		mx.parent.Error(h)
+		return
+	}
 	mx.errorHandler = h
 }
 
@@ -386,14 +633,7 @@ func (mx *Mux) Error(h ErrorHandlerFunc) {
 // from here
 func (mx *Mux) ToHTTPHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		err := mx.ServeHTTP(w, r)
-		if err != nil {
-			if mx.errorHandler == nil {
-				defaultErrorHandler(err, w, r)
-			} else {
-				mx.errorHandler(err, w, r)
-			}
-		}
+		mx.serveHTTP(w, r, mx.ErrorHandler())
 	})
 }
 
@@ -405,6 +645,15 @@ func (mx *Mux) buildRouteHandler() {
 	mx.handler = chain(mx.middlewares, HandlerFunc(mx.routeHTTP))
 }
 
+// routeHandler returns the handler built by buildRouteHandler, with the
+// middlewares in spans if the request is traced.
+func (mx *Mux) routeHandler(rctx *Context) Handler {
+	if rctx.tracer != nil && !mx.inline {
+		return mx.traced.handler(mx.middlewares, HandlerFunc(mx.routeHTTP))
+	}
+	return mx.handler
+}
+
 // handle registers a Handler in the routing tree for a particular http method
 // and routing pattern.
 func (mx *Mux) handle(method methodTyp, pattern string, handler Handler) *node {
@@ -426,8 +675,36 @@ func (mx *Mux) handle(method methodTyp, pattern string, handler Handler) *node {
 		h = handler
 	}
 
+	if method&mSTUB == 0 {
+		mx.nameRoute(pattern)
+	}
+
 	// Add the endpoint to the tree and return the node
-	return mx.tree.InsertRoute(method, pattern, h)
+	return mx.tree.insertRoute(method, pattern, h, mx.conditions, mx.meta)
+}
+
+// nameRoute records `pattern` as the routing pattern of the route named by an
+// inline mux created by Named. A name can be registered again for other
+// methods of the same pattern.
+func (mx *Mux) nameRoute(pattern string) {
+	if mx.routeName == "" {
+		return
+	}
+	m := mx
+	for m.inline && m.parent != nil {
+		m = m.parent
+	}
+	if p, ok := m.routeNames[mx.routeName]; ok && p != pattern {
+		panic(fmt.Sprintf("chi: route name '%s' is already in use for '%s'", mx.routeName, p))
+	} else if !ok {
+		if patterns, ok := m.namedRoute(mx.routeName); ok {
+			panic(fmt.Sprintf("chi: route name '%s' is already in use for '%s'", mx.routeName, replaceWildcards(strings.Join(patterns, ""))))
+		}
+	}
+	if m.routeNames == nil {
+		m.routeNames = make(map[string]string)
+	}
+	m.routeNames[mx.routeName] = pattern
 }
 
 // routeHTTP routes a http.Request through the Mux routing tree to serve
@@ -455,16 +732,100 @@ func (mx *Mux) routeHTTP(w http.ResponseWriter, r *http.Request) HandlerError {
 		return mx.MethodNotAllowedHandler().ServeHTTP(w, r)
 	}
 
+	// Apply the path policy
+	switch mx.pathPolicy {
+	case PathStrip, PathClean:
+		routePath = mx.pathPolicy.normalize(routePath)
+	case PathRedirectPermanent, PathRedirectTemporary:
+		if p := canonicalPath(routePath); p != routePath &&
+			!mx.matchPath(rctx, r, routePath) && mx.matchPath(rctx, r, p) {
+			http.Redirect(w, r, redirectLocation(r, routePath, p), mx.pathPolicy.redirectCode(r.Method))
+			return nil
+		}
+	}
+
+	// Route by host before routing the path
+	if mx.hosts != nil {
+		if _, h := mx.findHost(rctx, routeHost(rctx, r)); h != nil {
+			rctx.RoutePath = routePath
+			return h.ServeHTTP(w, r)
+		}
+	}
+
 	// Find the route
-	if _, _, h := mx.tree.FindRoute(rctx, method, routePath); h != nil {
-		return h.ServeHTTP(w, r)
+	rctx.autoHead = mx.autoHead
+	rctx.caseInsensitive = mx.caseSensitivity != CaseSensitive
+	if n, eps, h := mx.tree.FindRoute(rctx, method, routePath); h != nil {
+		if mx.caseSensitivity == CaseInsensitiveRedirect && mx.redirectCase(w, r, rctx, n, routePath) {
+			return nil
+		}
+		if ep, ok := h.(*endpoint); ok {
+			// Match the conditional endpoints on the request
+			var meta Meta
+			var status int
+			if h, meta, status = ep.match(r); h == nil {
+				if status == http.StatusNotFound {
+					return mx.NotFoundHandler().ServeHTTP(w, r)
+				}
+				return Error{Code: status}
+			}
+			rctx.routeMeta = rctx.routeMeta.merge(meta)
+		}
+		kind := SpanHandler
+		if n.subroutes != nil {
+			kind = SpanMount
+		}
+		if method == mHEAD && !eps[mHEAD].routable() {
+			// Routed to the GET handler, so only the headers are sent
+			hw := &headResponseWriter{ResponseWriter: w}
+			err := rctx.serveRoute(hw, r, kind, h)
+			hw.finish()
+			return err
+		}
+		return rctx.serveRoute(w, r, kind, h)
 	}
-	if rctx.methodNotAllowed {
-		return mx.MethodNotAllowedHandler().ServeHTTP(w, r)
-	} else {
+	if !rctx.methodNotAllowed {
 		return mx.NotFoundHandler().ServeHTTP(w, r)
 	}
 
+	if mx.autoOptions {
+		rctx.methodsAllowed |= mOPTIONS
+	}
+	if mx.autoHead && rctx.methodsAllowed&mGET != 0 {
+		rctx.methodsAllowed |= mHEAD
+	}
+	w.Header().Set("Allow", strings.Join(rctx.AllowedMethods(), ", "))
+	if method == mOPTIONS && mx.autoOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	return mx.MethodNotAllowedHandler().ServeHTTP(w, r)
+}
+
+// redirectCase records the routing path in the case of the matched routing
+// pattern, and redirects the request once the endpoint is found. Mounted
+// sub-routers complete the path of their parent, so the client is redirected
+// only once.
+func (mx *Mux) redirectCase(w http.ResponseWriter, r *http.Request, rctx *Context, n *node, routePath string) bool {
+	if rctx.caseFolded {
+		canonical := expandPattern(rctx.routePattern, rctx.routeParams.Values)
+		offset := len(rctx.foldedPath) - len(routePath)
+		switch {
+		case len(canonical) != len(routePath) || canonical == routePath:
+		case rctx.foldedPath == "":
+			rctx.foldedPath = routePath
+			rctx.canonicalPath = canonical
+		case offset >= 0 && strings.EqualFold(rctx.foldedPath[offset:], routePath):
+			rctx.canonicalPath = rctx.canonicalPath[:offset] + canonical
+		}
+	}
+
+	if n.subroutes != nil || rctx.foldedPath == "" {
+		return false
+	}
+	location := redirectLocation(r, rctx.foldedPath, rctx.canonicalPath)
+	http.Redirect(w, r, location, PathRedirectPermanent.redirectCode(r.Method))
+	return true
 }
 
 func (mx *Mux) nextRoutePath(rctx *Context) string {
@@ -476,9 +837,33 @@ func (mx *Mux) nextRoutePath(rctx *Context) string {
 	return routePath
 }
 
+// configureSubRouter assigns the not found & method not allowed handlers of the
+// Mux to `subr` if not specified, and enables the routing options of the Mux.
+func (mx *Mux) configureSubRouter(subr *Mux) {
+	if subr.notFoundHandler == nil && mx.notFoundHandler != nil {
+		subr.NotFound(mx.notFoundHandler)
+	}
+	if subr.methodNotAllowedHandler == nil && mx.methodNotAllowedHandler != nil {
+		subr.MethodNotAllowed(mx.methodNotAllowedHandler)
+	}
+	if mx.autoOptions {
+		subr.AutoOptions(true)
+	}
+	if mx.autoHead {
+		subr.AutoHead(true)
+	}
+	if mx.caseSensitivity != CaseSensitive {
+		subr.CaseSensitivity(mx.caseSensitivity)
+	}
+}
+
 // Recursively update data on child routers.
 func (mx *Mux) updateSubRoutes(fn func(subMux *Mux)) {
-	for _, r := range mx.tree.routes() {
+	routes := mx.tree.routes()
+	if mx.hosts != nil {
+		routes = append(routes, mx.hosts.routes()...)
+	}
+	for _, r := range routes {
 		subMux, ok := r.SubRoutes.(*Mux)
 		if !ok {
 			continue
@@ -487,6 +872,79 @@ func (mx *Mux) updateSubRoutes(fn func(subMux *Mux)) {
 	}
 }
 
+// headResponseWriter discards the response body of a GET handler serving a
+// HEAD request. The headers are held back until the handler returns, so the
+// Content-Length header can be set from the number of bytes written.
+type headResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	length      int
+	wroteHeader bool
+}
+
+func (w *headResponseWriter) WriteHeader(code int) {
+	if code >= 100 && code <= 199 && code != http.StatusSwitchingProtocols {
+		// Informational responses are sent right away
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *headResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.length += len(b)
+	return len(b), nil
+}
+
+// Flush sends the headers, as the handler is streaming its response and the
+// final length of the body is unknown.
+func (w *headResponseWriter) Flush() {
+	w.writeHeader()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// finish sends the headers, if the handler wrote a response.
+func (w *headResponseWriter) finish() {
+	if w.status == 0 || w.wroteHeader {
+		return
+	}
+	h := w.ResponseWriter.Header()
+	if h.Get("Content-Length") == "" && h.Get("Transfer-Encoding") == "" && bodyAllowedForStatus(w.status) {
+		h.Set("Content-Length", strconv.Itoa(w.length))
+	}
+	w.writeHeader()
+}
+
+func (w *headResponseWriter) writeHeader() {
+	if w.wroteHeader {
+		return
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// bodyAllowedForStatus reports whether a response with the given status code
+// may have a body, see RFC 9110, section 6.4.1.
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
 // methodNotAllowedHandler is a helper function to respond with a 405,
 // method not allowed.
 func methodNotAllowedHandler(_ http.ResponseWriter, _ *http.Request) HandlerError {
@@ -499,9 +957,10 @@ func notFoundHandler(_ http.ResponseWriter, _ *http.Request) HandlerError {
 	return Error{Code: http.StatusNotFound}
 }
 
-// defaultErrorHandler is a function to send an HandlerError
-// that occurred middleware or handler function via http.Error
-func defaultErrorHandler(err HandlerError, w http.ResponseWriter, _ *http.Request) {
+// DefaultErrorHandler is the ErrorHandlerFunc used by the ToHTTP adapters
+// and by a Mux without a custom error handler. It responds with the
+// error's status code and message via http.Error.
+func DefaultErrorHandler(err HandlerError, w http.ResponseWriter, _ *http.Request) {
 	s := err.Error()
 	if s == "" {
 		s = http.StatusText(err.StatusCode())
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -486,6 +487,159 @@ func TestMuxNestedMethodNotAllowed(t *testing.T) {
 	}
 }
 
+func TestMuxMethodNotAllowedAllow(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) HandlerError {
+		w.Write([]byte("ok"))
+		return nil
+	}
+
+	r := NewRouter()
+	r.Get("/articles/{id}", h)
+	r.Put("/articles/{id}", h)
+	r.Delete("/articles/{id}", h)
+	r.Route("/users", func(r Router) {
+		r.Post("/", h)
+		r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) HandlerError {
+			methods := RouteContext(r.Context()).AllowedMethods()
+			w.WriteHeader(405)
+			w.Write([]byte(strings.Join(methods, ",")))
+			return nil
+		})
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	resp, _ := testRequest(t, ts, "POST", "/articles/1", nil)
+	if resp.StatusCode != 405 {
+		t.Fatalf("expecting status 405, got %d", resp.StatusCode)
+	}
+	if allow := resp.Header.Get("Allow"); allow != "DELETE, GET, PUT" {
+		t.Fatalf("expecting Allow header 'DELETE, GET, PUT', got '%s'", allow)
+	}
+
+	resp, body := testRequest(t, ts, "GET", "/users/", nil)
+	if resp.StatusCode != 405 || body != "POST" {
+		t.Fatalf("expecting 405 with body 'POST', got %d with '%s'", resp.StatusCode, body)
+	}
+	if allow := resp.Header.Get("Allow"); allow != "POST" {
+		t.Fatalf("expecting Allow header 'POST', got '%s'", allow)
+	}
+
+	if resp, _ := testRequest(t, ts, "OPTIONS", "/articles/1", nil); resp.StatusCode != 405 {
+		t.Fatalf("expecting status 405 without AutoOptions, got %d", resp.StatusCode)
+	}
+}
+
+func TestMuxAutoOptions(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) HandlerError {
+		w.Write([]byte("ok"))
+		return nil
+	}
+
+	r := NewRouter()
+	r.AutoOptions(true)
+	r.Get("/ping", h)
+	r.Post("/ping", h)
+	r.Options("/custom", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		w.Write([]byte("custom options"))
+		return nil
+	})
+	r.Get("/custom", h)
+	r.Route("/sub", func(r Router) {
+		r.Patch("/{id}", h)
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	tests := []struct {
+		method, path string
+		status       int
+		allow        string
+		body         string
+	}{
+		{"OPTIONS", "/ping", 204, "GET, OPTIONS, POST", ""},
+		{"OPTIONS", "/sub/1", 204, "OPTIONS, PATCH", ""},
+		{"OPTIONS", "/custom", 200, "", "custom options"},
+		{"PUT", "/ping", 405, "GET, OPTIONS, POST", "Method Not Allowed\n"},
+		{"OPTIONS", "/nothing", 404, "", "Not Found\n"},
+	}
+	for _, tt := range tests {
+		resp, body := testRequest(t, ts, tt.method, tt.path, nil)
+		if resp.StatusCode != tt.status || body != tt.body {
+			t.Fatalf("%s %s: expecting %d with '%s', got %d with '%s'", tt.method, tt.path, tt.status, tt.body, resp.StatusCode, body)
+		}
+		if allow := resp.Header.Get("Allow"); allow != tt.allow {
+			t.Fatalf("%s %s: expecting Allow header '%s', got '%s'", tt.method, tt.path, tt.allow, allow)
+		}
+	}
+}
+
+func TestMuxAutoHead(t *testing.T) {
+	r := NewRouter()
+	r.AutoHead(true)
+	r.Get("/hi", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		w.Header().Set("X-Method", r.Method)
+		w.Write([]byte("hi there"))
+		return nil
+	})
+	r.Get("/explicit", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		w.Write([]byte("get"))
+		return nil
+	})
+	r.Head("/explicit", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		w.Header().Set("X-Head", "yes")
+		return nil
+	})
+	r.Post("/post", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		return nil
+	})
+	r.Route("/sub", func(r Router) {
+		r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) HandlerError {
+			w.Write([]byte("sub " + URLParam(r, "id")))
+			return nil
+		})
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	resp, body := testRequest(t, ts, "HEAD", "/hi", nil)
+	if resp.StatusCode != 200 || body != "" {
+		t.Fatalf("expecting 200 without body, got %d with '%s'", resp.StatusCode, body)
+	}
+	if resp.Header.Get("X-Method") != "HEAD" || resp.ContentLength != 8 {
+		t.Fatalf("expecting GET handler with Content-Length 8, got %v", resp.Header)
+	}
+
+	resp, body, _ = testHandler(t, r, "HEAD", "/hi", nil)
+	if body != "" || resp.Header.Get("Content-Length") != "8" {
+		t.Fatalf("expecting body to be discarded with Content-Length 8, got '%s' with %v", body, resp.Header)
+	}
+
+	if resp, _ := testRequest(t, ts, "HEAD", "/explicit", nil); resp.Header.Get("X-Head") != "yes" {
+		t.Fatal("expecting explicit HEAD handler to be used")
+	}
+	if resp, _ := testRequest(t, ts, "HEAD", "/sub/123", nil); resp.StatusCode != 200 || resp.ContentLength != 7 {
+		t.Fatalf("expecting 200 with Content-Length 7 from sub-router, got %d with %d", resp.StatusCode, resp.ContentLength)
+	}
+	if resp, _ := testRequest(t, ts, "HEAD", "/post", nil); resp.StatusCode != 405 {
+		t.Fatalf("expecting 405, got %d", resp.StatusCode)
+	}
+	if resp, _ := testRequest(t, ts, "PUT", "/hi", nil); resp.Header.Get("Allow") != "GET, HEAD" {
+		t.Fatalf("expecting Allow header 'GET, HEAD', got '%s'", resp.Header.Get("Allow"))
+	}
+
+	r2 := NewRouter()
+	r2.Get("/hi", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		return nil
+	})
+	if _, _, err := testHandler(t, r2, "HEAD", "/hi", nil); err == nil || err.StatusCode() != 405 {
+		t.Fatalf("expecting 405 without AutoHead, got %v", err)
+	}
+}
+
 func TestMuxComplicatedNotFound(t *testing.T) {
 	decorateRouter := func(r *Mux) {
 		// Root router with groups
@@ -1641,7 +1795,8 @@ func TestMuxContextIsThreadSafe(t *testing.T) {
 				w := httptest.NewRecorder()
 				r, err := http.NewRequest("GET", "/ok", nil)
 				if err != nil {
-					t.Fatal(err)
+					t.Error(err)
+					return
 				}
 
 				ctx, cancel := context.WithCancel(r.Context())
@@ -1756,7 +1911,7 @@ func TestServerBaseContext(t *testing.T) {
 
 	// Setup http Server with a base context
 	ctx := context.WithValue(context.Background(), ctxKey{"base"}, "yes")
-	ts := httptest.NewServer(ServerBaseContext(ctx, r.ToHTTPHandler()))
+	ts := httptest.NewServer(ToHTTPHandler(ServerBaseContext(ctx, r), nil))
 	defer ts.Close()
 
 	if _, body := testRequest(t, ts, "GET", "/", nil); body != "yes" {
@@ -1764,6 +1919,163 @@ func TestServerBaseContext(t *testing.T) {
 	}
 }
 
+func TestMuxErrorHandlerContext(t *testing.T) {
+	reqIDmw := func(next Handler) Handler {
+		return HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError {
+			ctx := context.WithValue(r.Context(), ctxKey{"reqID"}, "req-1")
+			return next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+	authmw := func(next Handler) Handler {
+		return HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError {
+			if r.Header.Get("Authorization") == "" {
+				return Error{Code: http.StatusUnauthorized}
+			}
+			return next.ServeHTTP(w, r)
+		})
+	}
+
+	r := NewRouter()
+	r.Use(reqIDmw)
+	r.Error(func(err HandlerError, w http.ResponseWriter, r *http.Request) {
+		rctx := RouteContext(r.Context())
+		reqID, _ := r.Context().Value(ctxKey{"reqID"}).(string)
+		w.WriteHeader(err.StatusCode())
+		w.Write([]byte(fmt.Sprintf("%s %s %s %s", reqID, rctx.RoutePattern(), rctx.URLParam("id"), err)))
+	})
+	r.Route("/articles", func(r Router) {
+		r.With(authmw).Get("/{id}", func(w http.ResponseWriter, r *http.Request) HandlerError {
+			return Error{Code: http.StatusNotFound, Err: errors.New("no article")}
+		})
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	req, _ := http.NewRequest("GET", ts.URL+"/articles/5", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 401 || string(body) != "req-1 /articles/{id} 5 Unauthorized" {
+		t.Fatalf("unexpected response %d %q", resp.StatusCode, body)
+	}
+
+	req.Header.Set("Authorization", "yes")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 404 || string(body) != "req-1 /articles/{id} 5 no article" {
+		t.Fatalf("unexpected response %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestMuxErrorHandlerReplacedError(t *testing.T) {
+	replacemw := func(next Handler) Handler {
+		return HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError {
+			if err := next.ServeHTTP(w, r); err != nil {
+				return Error{Code: http.StatusBadGateway}
+			}
+			return nil
+		})
+	}
+	tagmw := func(next Handler) Handler {
+		return HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError {
+			ctx := context.WithValue(r.Context(), ctxKey{"tag"}, "inner")
+			return next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+
+	r := NewRouter()
+	r.Use(replacemw)
+	r.Error(func(err HandlerError, w http.ResponseWriter, r *http.Request) {
+		tag, _ := r.Context().Value(ctxKey{"tag"}).(string)
+		w.WriteHeader(err.StatusCode())
+		w.Write([]byte(fmt.Sprintf("%s %s", tag, err)))
+	})
+	r.With(tagmw).Get("/", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		return Error{Code: http.StatusNotFound}
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	if resp, body := testRequest(t, ts, "GET", "/", nil); resp.StatusCode != 502 || body != " Bad Gateway" {
+		t.Fatalf("expecting the replaced error with the outer request, got %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestMuxSubrouterErrorHandlers(t *testing.T) {
+	errorHandler := func(name string) ErrorHandlerFunc {
+		return func(err HandlerError, w http.ResponseWriter, r *http.Request) {
+			if err.StatusCode() == http.StatusTeapot {
+				PassError(r, Error{Code: http.StatusTeapot, Err: fmt.Errorf("%s: %v", name, err)})
+				return
+			}
+			w.WriteHeader(err.StatusCode())
+			w.Write([]byte(fmt.Sprintf("%s %s", name, err)))
+		}
+	}
+	failing := func(code int) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) HandlerError {
+			return Error{Code: code}
+		}
+	}
+
+	r := NewRouter()
+	r.Error(errorHandler("root"))
+	r.Get("/fail", failing(http.StatusBadRequest))
+
+	r.Route("/api", func(r Router) {
+		r.Error(errorHandler("api"))
+		r.Get("/fail", failing(http.StatusBadRequest))
+		r.Get("/teapot", failing(http.StatusTeapot))
+		r.Route("/v1", func(r Router) {
+			r.Get("/fail", failing(http.StatusConflict))
+		})
+	})
+
+	admin := NewRouter()
+	admin.Group(func(r Router) {
+		r.Error(errorHandler("admin"))
+	})
+	admin.Get("/fail", failing(http.StatusForbidden))
+	r.Mount("/admin", admin)
+
+	r.Route("/plain", func(r Router) {
+		r.Get("/fail", failing(http.StatusBadGateway))
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	testcases := []struct {
+		Path           string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{"/fail", 400, "root Bad Request"},
+		{"/api/fail", 400, "api Bad Request"},
+		{"/api/nothing", 404, "api Not Found"},
+		{"/api/v1/fail", 409, "api Conflict"},
+		{"/api/teapot", 418, "root: api: I'm a teapot\n"},
+		{"/admin/fail", 403, "admin Forbidden"},
+		{"/plain/fail", 502, "root Bad Gateway"},
+	}
+
+	for _, tc := range testcases {
+		resp, body := testRequest(t, ts, "GET", tc.Path, nil)
+		if resp.StatusCode != tc.ExpectedStatus || body != tc.ExpectedBody {
+			t.Fatalf("%s: expecting %d %q, got %d %q", tc.Path, tc.ExpectedStatus, tc.ExpectedBody, resp.StatusCode, body)
+		}
+	}
+}
+
 func testRequest(t *testing.T, ts *httptest.Server, method, path string, body io.Reader) (*http.Response, string) {
 	req, err := http.NewRequest(method, ts.URL+path, body)
 	if err != nil {
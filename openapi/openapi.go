@@ -0,0 +1,363 @@
+// Package openapi generates OpenAPI 3 documents from the routes of a chi
+// router.
+//
+// Every method and route visited by chi.WalkMeta becomes an operation of the
+// document, and the URL params of its routing pattern become path params,
+// whose schemas follow their regexps and named matchers. Operations are
+// annotated by wrapping their handler with Describe when registering the
+// route, or with the route metadata MetaKey:
+//
+//   r.Method("POST", "/users", openapi.Describe(chi.HandlerFunc(createUser), openapi.Doc{
+//     Summary:   "Create a user",
+//     Tags:      []string{"users"},
+//     Request:   UserRequest{},
+//     Responses: map[int]interface{}{201: User{}},
+//   }))
+//   r.Method("GET", "/openapi.json", openapi.Handler(r, openapi.Info{Title: "Users", Version: "1.0"}))
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/SirAiedail/chi"
+)
+
+// Version is the version of the OpenAPI specification of the generated
+// documents.
+const Version = "3.0.3"
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI string               `json:"openapi"`
+	Info    Info                 `json:"info"`
+	Servers []Server             `json:"servers,omitempty"`
+	Paths   map[string]*PathItem `json:"paths"`
+}
+
+// Info is the metadata of the API described by a Document.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a server serving the API, or the paths of a PathItem.
+type Server struct {
+	URL       string                    `json:"url"`
+	Variables map[string]ServerVariable `json:"variables,omitempty"`
+}
+
+// ServerVariable is a variable of a Server URL, such as a host param.
+type ServerVariable struct {
+	Default string `json:"default"`
+}
+
+// PathItem holds the operations of a path.
+type PathItem struct {
+	Servers []Server   `json:"servers,omitempty"`
+	Get     *Operation `json:"get,omitempty"`
+	Put     *Operation `json:"put,omitempty"`
+	Post    *Operation `json:"post,omitempty"`
+	Delete  *Operation `json:"delete,omitempty"`
+	Options *Operation `json:"options,omitempty"`
+	Head    *Operation `json:"head,omitempty"`
+	Patch   *Operation `json:"patch,omitempty"`
+	Trace   *Operation `json:"trace,omitempty"`
+}
+
+// Operation is an operation of the API, a method of a path.
+type Operation struct {
+	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
+	OperationID string              `json:"operationId,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+	Deprecated  bool                `json:"deprecated,omitempty"`
+}
+
+// Parameter is a param of an Operation.
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema,omitempty"`
+}
+
+// RequestBody is the request body of an Operation.
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response of an Operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a request or response body.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Doc annotates the operation of a route, see Describe.
+type Doc struct {
+	Summary     string
+	Description string
+	OperationID string
+	Tags        []string
+	Deprecated  bool
+
+	// Request is a value of the type of the JSON request body, or nil for a
+	// request without a body.
+	Request interface{}
+
+	// Responses are values of the types of the JSON response bodies by their
+	// status code, or nil for a response without a body. Without responses,
+	// the operation is documented with a 200 OK response.
+	Responses map[int]interface{}
+}
+
+// MetaKey is the key of the route metadata annotating the operation of the
+// route with a Doc, as an alternative to Describe:
+//
+//   r.WithMeta(chi.Meta{openapi.MetaKey: openapi.Doc{Summary: "List users"}}).Get("/users", listUsers)
+const MetaKey = "openapi"
+
+// describedHandler is a handler annotated by Describe.
+type describedHandler struct {
+	chi.Handler
+	doc Doc
+}
+
+// Describe returns `h` annotated with `doc` for the operation of its route.
+func Describe(h chi.Handler, doc Doc) chi.Handler {
+	return &describedHandler{h, doc}
+}
+
+// Generate returns the OpenAPI document of the routes of `r`, including the
+// routes of its mounted sub-routers and hosts. The routes of a host are
+// documented with the host as the server of their paths. Routes ending with
+// a wildcard '*' have no OpenAPI equivalent and are left out. Generate returns
+// an error for routes of the same method that only differ by the matchers of
+// their params, such as "/users/{id}" and "/users/{id:int}", as they have the
+// same OpenAPI path. A route with conditional handlers registered with
+// chi.Mux.When is documented by its unconditional handler, or its first
+// conditional handler without one.
+func Generate(r chi.Routes, info Info) (*Document, error) {
+	doc := &Document{OpenAPI: Version, Info: info, Paths: map[string]*PathItem{}}
+
+	// Routes of the operations, to detect routes with the same path template
+	routes := map[string]string{}
+
+	err := chi.WalkMeta(r, func(method string, route string, handler chi.Handler, meta chi.Meta, middlewares ...func(chi.Handler) chi.Handler) error {
+		if strings.HasSuffix(route, "*") {
+			return nil
+		}
+
+		var server *Server
+		if route[0] != '/' {
+			i := strings.IndexByte(route, '/')
+			host, _ := parsePattern(route[:i])
+			server = &Server{URL: "//" + host, Variables: serverVariables(host)}
+			route = route[i:]
+		}
+
+		path, params := parsePattern(route)
+		key := method + " " + path
+		if server != nil {
+			key += " " + server.URL
+		}
+		if other, ok := routes[key]; ok {
+			if other == route {
+				// Further handler of a conditional route
+				return nil
+			}
+			if other > route {
+				other, route = route, other
+			}
+			return fmt.Errorf("openapi: %s routes '%s' and '%s' have the same path '%s'", method, other, route, path)
+		}
+		routes[key] = route
+
+		item := doc.Paths[path]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		if server != nil && !hasServer(item.Servers, server.URL) {
+			item.Servers = append(item.Servers, *server)
+		}
+
+		op := newOperation(routeDoc(handler, meta), params)
+		switch method {
+		case "GET":
+			item.Get = op
+		case "PUT":
+			item.Put = op
+		case "POST":
+			item.Post = op
+		case "DELETE":
+			item.Delete = op
+		case "OPTIONS":
+			item.Options = op
+		case "HEAD":
+			item.Head = op
+		case "PATCH":
+			item.Patch = op
+		case "TRACE":
+			item.Trace = op
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Handler returns a handler serving the OpenAPI document of the routes of
+// `r` as JSON. The document is generated on the first request, once all
+// routes are registered.
+func Handler(r chi.Routes, info Info) chi.Handler {
+	var (
+		once sync.Once
+		body []byte
+		err  error
+	)
+	fn := func(w http.ResponseWriter, req *http.Request) chi.HandlerError {
+		once.Do(func() {
+			var doc *Document
+			if doc, err = Generate(r, info); err == nil {
+				body, err = json.Marshal(doc)
+			}
+		})
+		if err != nil {
+			return chi.Error{Code: http.StatusInternalServerError, Err: err}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+		return nil
+	}
+	return chi.HandlerFunc(fn)
+}
+
+// routeDoc returns the annotation of a route served by `handler` with the
+// metadata `meta`, or nil if it has none.
+func routeDoc(handler chi.Handler, meta chi.Meta) *Doc {
+	if d, ok := handler.(*describedHandler); ok {
+		return &d.doc
+	}
+	if doc, ok := meta[MetaKey].(Doc); ok {
+		return &doc
+	}
+	return nil
+}
+
+// newOperation returns the operation of a route annotated with `d`, with
+// the path params `params`.
+func newOperation(d *Doc, params []Parameter) *Operation {
+	op := &Operation{Parameters: params, Responses: map[string]Response{}}
+	if d == nil {
+		op.Responses["200"] = Response{Description: http.StatusText(http.StatusOK)}
+		return op
+	}
+
+	op.Summary = d.Summary
+	op.Description = d.Description
+	op.OperationID = d.OperationID
+	op.Tags = d.Tags
+	op.Deprecated = d.Deprecated
+
+	if d.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: SchemaOf(d.Request)}},
+		}
+	}
+
+	if len(d.Responses) == 0 {
+		op.Responses["200"] = Response{Description: http.StatusText(http.StatusOK)}
+	}
+	for status, v := range d.Responses {
+		resp := Response{Description: http.StatusText(status)}
+		if v != nil {
+			resp.Content = map[string]MediaType{"application/json": {Schema: SchemaOf(v)}}
+		}
+		op.Responses[strconv.Itoa(status)] = resp
+	}
+	return op
+}
+
+// parsePattern returns the OpenAPI path template of a routing pattern, such
+// as "/users/{id}" for "/users/{id:[0-9]+}", and its URL params.
+func parsePattern(pattern string) (string, []Parameter) {
+	var (
+		b      strings.Builder
+		params []Parameter
+	)
+	for {
+		ps := strings.IndexByte(pattern, '{')
+		if ps < 0 {
+			b.WriteString(pattern)
+			break
+		}
+
+		// Read to the closing } taking into account the braces of a regexp
+		cc, pe := 0, ps
+		for i := ps; i < len(pattern); i++ {
+			if pattern[i] == '{' {
+				cc++
+			} else if pattern[i] == '}' {
+				cc--
+				if cc == 0 {
+					pe = i
+					break
+				}
+			}
+		}
+		if pe == ps {
+			b.WriteString(pattern)
+			break
+		}
+
+		key, rexpat := pattern[ps+1:pe], ""
+		if i := strings.IndexByte(key, ':'); i >= 0 {
+			key, rexpat = key[:i], key[i+1:]
+		}
+		b.WriteString(pattern[:ps] + "{" + key + "}")
+		params = append(params, Parameter{Name: key, In: "path", Required: true, Schema: paramSchema(rexpat)})
+		pattern = pattern[pe+1:]
+	}
+	return b.String(), params
+}
+
+// serverVariables returns the variables of a host template, defaulting to
+// their names.
+func serverVariables(host string) map[string]ServerVariable {
+	_, params := parsePattern(host)
+	if len(params) == 0 {
+		return nil
+	}
+	vars := make(map[string]ServerVariable, len(params))
+	for _, p := range params {
+		vars[p.Name] = ServerVariable{Default: p.Name}
+	}
+	return vars
+}
+
+func hasServer(servers []Server, url string) bool {
+	for _, s := range servers {
+		if s.URL == url {
+			return true
+		}
+	}
+	return false
+}
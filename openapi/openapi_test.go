@@ -0,0 +1,169 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/SirAiedail/chi"
+)
+
+type testUser struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Email     *string   `json:"email,omitempty"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"created_at"`
+	Manager   *testUser `json:"manager"`
+	password  string
+	Ignored   string `json:"-"`
+}
+
+func TestGenerate(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) chi.HandlerError { return nil }
+
+	r := chi.NewRouter()
+	r.Get("/", h)
+	r.Route("/users", func(r chi.Router) {
+		r.Method("POST", "/", Describe(chi.HandlerFunc(h), Doc{
+			Summary:   "Create a user",
+			Tags:      []string{"users"},
+			Request:   testUser{},
+			Responses: map[int]interface{}{201: testUser{}, 400: nil},
+		}))
+		r.WithMeta(chi.Meta{MetaKey: Doc{Summary: "Get a user"}}).Get("/{id:int}", h)
+		r.Get("/{id:int}/posts/{slug:[a-z-]+}", h)
+	})
+	r.Mount("/static", chi.HandlerFunc(h))
+	r.Host("{tenant}.example.com", func(r chi.Router) {
+		r.Get("/dashboard", h)
+	})
+
+	doc, err := Generate(r, Info{Title: "Test", Version: "1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	if len(paths) != 5 {
+		t.Fatalf("expecting 5 paths, got %v", paths)
+	}
+
+	if op := doc.Paths["/"].Get; op == nil || op.Responses["200"].Description != "OK" {
+		t.Fatalf("expecting GET / with a 200 response, got %#v", op)
+	}
+
+	post := doc.Paths["/users/"].Post
+	if post == nil || post.Summary != "Create a user" || !reflect.DeepEqual(post.Tags, []string{"users"}) {
+		t.Fatalf("expecting described POST /users/, got %#v", post)
+	}
+	if _, ok := post.Responses["201"].Content["application/json"]; !ok || post.Responses["400"].Content != nil {
+		t.Fatalf("unexpected responses %#v", post.Responses)
+	}
+	user := post.RequestBody.Content["application/json"].Schema
+	if user.Type != "object" || len(user.Properties) != 6 ||
+		user.Properties["id"].Format != "int64" ||
+		user.Properties["created_at"].Format != "date-time" ||
+		!user.Properties["email"].Nullable ||
+		user.Properties["tags"].Items.Type != "string" ||
+		user.Properties["manager"].Type != "object" || user.Properties["manager"].Properties != nil {
+		b, _ := json.Marshal(user)
+		t.Fatalf("unexpected user schema %s", b)
+	}
+
+	if op := doc.Paths["/users/{id}"].Get; op.Summary != "Get a user" {
+		t.Fatalf("expecting GET /users/{id} described by its route metadata, got %#v", op)
+	}
+
+	params := doc.Paths["/users/{id}/posts/{slug}"].Get.Parameters
+	if len(params) != 2 || params[0].Name != "id" || params[0].In != "path" || !params[0].Required ||
+		params[0].Schema.Type != "integer" || params[1].Schema.Pattern != "^[a-z-]+$" {
+		t.Fatalf("unexpected params %#v", params)
+	}
+
+	dashboard := doc.Paths["/dashboard"]
+	if dashboard == nil || len(dashboard.Servers) != 1 || dashboard.Servers[0].URL != "//{tenant}.example.com" ||
+		dashboard.Servers[0].Variables["tenant"].Default != "tenant" {
+		t.Fatalf("expecting /dashboard served by the tenant host, got %#v", dashboard)
+	}
+}
+
+func TestGenerateParamMatchers(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) chi.HandlerError { return nil }
+	chi.RegisterParamMatcher("testslug", func(value string) bool { return value != "" })
+	chi.RegisterParamMatcher("alnum", func(value string) bool { return value != "" })
+
+	r := chi.NewRouter()
+	r.Get("/articles/{slug:testslug}", h)
+	r.Get("/codes/{code:alnum}", h)
+	r.Get("/pages/{n:int}", h)
+	doc, err := Generate(r, Info{Title: "Test", Version: "1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema := doc.Paths["/articles/{slug}"].Get.Parameters[0].Schema; schema.Type != "string" || schema.Pattern != "" {
+		t.Fatalf("expecting a plain string for a registered matcher, got %#v", schema)
+	}
+	if schema := doc.Paths["/codes/{code}"].Get.Parameters[0].Schema; schema.Type != "string" || schema.Pattern != "" {
+		t.Fatalf("expecting a plain string for an overridden built-in matcher, got %#v", schema)
+	}
+	if schema := doc.Paths["/pages/{n}"].Get.Parameters[0].Schema; schema.Type != "integer" {
+		t.Fatalf("expecting an integer for the built-in int matcher, got %#v", schema)
+	}
+
+	r.Post("/users/{id}", h)
+	r.Post("/users/{id:int}", h)
+	if _, err := Generate(r, Info{Title: "Test", Version: "1.0"}); err == nil ||
+		err.Error() != "openapi: POST routes '/users/{id:int}' and '/users/{id}' have the same path '/users/{id}'" {
+		t.Fatalf("expecting an error for routes with the same path, got %v", err)
+	}
+}
+
+func TestGenerateConditionalRoutes(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) chi.HandlerError { return nil }
+	mw := func(next chi.Handler) chi.Handler { return next }
+
+	r := chi.NewRouter()
+	r.When(chi.ContentType("application/json")).With(mw).Method("POST", "/articles", Describe(chi.HandlerFunc(h), Doc{Summary: "Create an article"}))
+	r.When(chi.ContentType("multipart/form-data")).Post("/articles", h)
+	doc, err := Generate(r, Info{Title: "Test", Version: "1.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if op := doc.Paths["/articles"].Post; op == nil || op.Summary != "Create an article" {
+		t.Fatalf("expecting the operation of the first conditional handler, got %#v", op)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := chi.NewRouter()
+	r.Method("GET", "/openapi.json", Handler(r, Info{Title: "Test", Version: "1.0"}))
+	r.Get("/ping", func(w http.ResponseWriter, r *http.Request) chi.HandlerError { return nil })
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var doc map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.Get("Content-Type") != "application/json" || doc["openapi"] != Version {
+		t.Fatalf("unexpected document %v", doc)
+	}
+	paths := doc["paths"].(map[string]interface{})
+	if _, ok := paths["/ping"]; !ok {
+		t.Fatalf("expecting /ping in document, got %v", paths)
+	}
+}
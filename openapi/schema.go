@@ -0,0 +1,160 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/SirAiedail/chi"
+)
+
+// Schema is the schema of a param or a request or response body.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// SchemaOf returns the schema of the JSON encoding of `v`, following the
+// rules of encoding/json for the names of struct fields. Values of types
+// with a custom MarshalJSON are documented without a type.
+func SchemaOf(v interface{}) *Schema {
+	return schemaOf(reflect.TypeOf(v), map[reflect.Type]bool{})
+}
+
+// schemaOf returns the schema of `t`. Types already in `seen` are recursive
+// and documented as plain objects.
+func schemaOf(t reflect.Type, seen map[reflect.Type]bool) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t.Implements(marshalerType) {
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := schemaOf(t.Elem(), seen)
+		s.Nullable = true
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: schemaOf(t.Elem(), seen)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOf(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return &Schema{Type: "object"}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		addProperties(s, t, seen)
+		return s
+	}
+	return &Schema{}
+}
+
+// addProperties adds the exported fields of the struct `t` to the
+// properties of `s`, including the fields of embedded structs.
+func addProperties(s *Schema, t reflect.Type, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := tag
+		if i := strings.IndexByte(tag, ','); i >= 0 {
+			name = tag[:i]
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			addProperties(s, ft, seen)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = schemaOf(f.Type, seen)
+	}
+}
+
+// paramSchema returns the schema of a URL param with the regexp or named
+// matcher `rexpat`. A param with a named matcher registered with
+// chi.RegisterParamMatcher, including one overriding a built-in matcher, is
+// a plain string, as the values it accepts are unknown.
+func paramSchema(rexpat string) *Schema {
+	if rexpat == "" {
+		return &Schema{Type: "string"}
+	}
+
+	fn, builtin := chi.LookupParamMatcher(rexpat)
+	if fn != nil && !builtin {
+		return &Schema{Type: "string"}
+	}
+	switch rexpat {
+	case "int":
+		return &Schema{Type: "integer"}
+	case "uint":
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case "uuid":
+		return &Schema{Type: "string", Format: "uuid"}
+	case "alpha":
+		return &Schema{Type: "string", Pattern: "^[A-Za-z]+$"}
+	case "alnum":
+		return &Schema{Type: "string", Pattern: "^[A-Za-z0-9]+$"}
+	case "date":
+		return &Schema{Type: "string", Format: "date"}
+	}
+
+	// Routing regexps are anchored, see chi's patNextSegment
+	if rexpat[0] != '^' {
+		rexpat = "^" + rexpat
+	}
+	if rexpat[len(rexpat)-1] != '$' {
+		rexpat += "$"
+	}
+	return &Schema{Type: "string", Pattern: rexpat}
+}
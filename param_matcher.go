@@ -0,0 +1,149 @@
+package chi
+
+import (
+	"fmt"
+	"sync"
+)
+
+// builtinParamMatchers are the built-in named matchers of URL params, such
+// as `{id:int}`.
+var builtinParamMatchers = map[string]func(value string) bool{
+	"int":   matchInt,
+	"uint":  matchUint,
+	"uuid":  matchUUID,
+	"alpha": matchAlpha,
+	"alnum": matchAlnum,
+	"date":  matchDate,
+}
+
+// paramMatchers are the named matchers registered with RegisterParamMatcher,
+// guarded by paramMatchersMu as they may be registered while URLFor reads
+// them.
+var (
+	paramMatchersMu sync.RWMutex
+	paramMatchers   = map[string]func(value string) bool{}
+)
+
+// RegisterParamMatcher adds a named matcher for URL params, available in
+// routing patterns as `{key:name}`. The routing path only matches if `fn`
+// returns true for the param value. A named matcher takes precedence over a
+// regexp with the same text, and needs to be registered before the routes
+// using it, as routes keep the matcher they were added with.
+//
+// The following matchers are built-in, and registering a matcher with one of
+// their names overrides it for the routes added afterwards:
+//
+//   int    optionally signed decimal integer, such as -42
+//   uint   unsigned decimal integer, such as 42
+//   uuid   UUID in its canonical form, such as f47ac10b-58cc-4372-a567-0e02b2c3d479
+//   alpha  ASCII letters
+//   alnum  ASCII letters and digits
+//   date   date in the form YYYY-MM-DD, such as 2006-01-02
+//
+// RegisterParamMatcher is safe for concurrent use, although matchers are
+// typically registered at init time.
+func RegisterParamMatcher(name string, fn func(value string) bool) {
+	if name == "" || fn == nil {
+		panic(fmt.Sprintf("chi: invalid param matcher '%s'", name))
+	}
+	paramMatchersMu.Lock()
+	paramMatchers[name] = fn
+	paramMatchersMu.Unlock()
+}
+
+// paramMatcher returns the named matcher `name`, a registered one before a
+// built-in one.
+func paramMatcher(name string) (func(value string) bool, bool) {
+	paramMatchersMu.RLock()
+	fn, ok := paramMatchers[name]
+	paramMatchersMu.RUnlock()
+	if !ok {
+		fn, ok = builtinParamMatchers[name]
+	}
+	return fn, ok
+}
+
+// LookupParamMatcher returns the named matcher `name` of URL params, or nil
+// if there's none, and whether it's a built-in matcher that wasn't overridden
+// with RegisterParamMatcher. Tooling documenting the routes visited by Walk
+// can use it to tell named matchers from regexps in routing patterns.
+func LookupParamMatcher(name string) (fn func(value string) bool, builtin bool) {
+	paramMatchersMu.RLock()
+	fn, ok := paramMatchers[name]
+	paramMatchersMu.RUnlock()
+	if ok {
+		return fn, false
+	}
+	fn, ok = builtinParamMatchers[name]
+	return fn, ok
+}
+
+func matchInt(s string) bool {
+	if len(s) > 1 && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+	return matchUint(s)
+}
+
+func matchUint(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchUUID(s string) bool {
+	_, err := parseUUID(s)
+	return err == nil
+}
+
+func matchAlpha(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isAlpha(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchAlnum(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isAlpha(s[i]) && !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchDate(s string) bool {
+	if len(s) != 10 || s[4] != '-' || s[7] != '-' {
+		return false
+	}
+	for _, i := range [8]int{0, 1, 2, 3, 5, 6, 8, 9} {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	month := (s[5]-'0')*10 + s[6] - '0'
+	day := (s[8]-'0')*10 + s[9] - '0'
+	return month >= 1 && month <= 12 && day >= 1 && day <= 31
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isAlpha(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
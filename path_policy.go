@@ -0,0 +1,117 @@
+package chi
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// PathPolicy controls how a Mux treats request paths that aren't in their
+// canonical form, i.e. paths with a trailing slash, duplicate slashes or
+// "." and ".." segments. See Mux.PathPolicy.
+type PathPolicy int
+
+const (
+	// PathStrict routes request paths as they are. It's the default policy.
+	PathStrict PathPolicy = iota
+
+	// PathStrip strips a trailing slash from the request path before routing,
+	// like middleware.StripSlashes.
+	PathStrip
+
+	// PathClean routes the canonical form of the request path, without
+	// redirecting the client.
+	PathClean
+
+	// PathRedirectPermanent redirects the client to the canonical form of the
+	// request path with a 301 Moved Permanently, or a 308 Permanent Redirect
+	// for methods other than GET and HEAD.
+	PathRedirectPermanent
+
+	// PathRedirectTemporary redirects the client to the canonical form of the
+	// request path with a 302 Found, or a 307 Temporary Redirect for methods
+	// other than GET and HEAD.
+	PathRedirectTemporary
+)
+
+// CaseSensitivity controls how a Mux matches the static segments of routing
+// patterns against request paths that differ in case. URL params always keep
+// the case of the request path. See Mux.CaseSensitivity.
+type CaseSensitivity int
+
+const (
+	// CaseSensitive matches static segments byte for byte. It's the default.
+	CaseSensitive CaseSensitivity = iota
+
+	// CaseInsensitive matches static segments regardless of case.
+	CaseInsensitive
+
+	// CaseInsensitiveRedirect matches static segments regardless of case, and
+	// redirects the client to the request path in the case of the routing
+	// patterns, like PathRedirectPermanent.
+	CaseInsensitiveRedirect
+)
+
+// canonicalPath returns the shortest equivalent of the routing path `p`,
+// without a trailing slash.
+func canonicalPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return path.Clean("/" + p)
+}
+
+// normalize returns the routing path `p` rewritten by the policy. Redirect
+// policies leave the path as is, they're applied by Mux.routeHTTP.
+func (pp PathPolicy) normalize(p string) string {
+	switch pp {
+	case PathStrip:
+		if len(p) > 1 && p[len(p)-1] == '/' {
+			return p[:len(p)-1]
+		}
+	case PathClean:
+		return canonicalPath(p)
+	}
+	return p
+}
+
+// redirectCode returns the status code of a redirect for the request method.
+func (pp PathPolicy) redirectCode(method string) int {
+	preserveMethod := method != http.MethodGet && method != http.MethodHead
+	switch {
+	case pp == PathRedirectPermanent && preserveMethod:
+		return http.StatusPermanentRedirect
+	case pp == PathRedirectPermanent:
+		return http.StatusMovedPermanently
+	case preserveMethod:
+		return http.StatusTemporaryRedirect
+	default:
+		return http.StatusFound
+	}
+}
+
+// redirectLocation returns the location to redirect a request with the
+// routing path `routePath` to, once the routing path is replaced by `target`.
+// The routing path is the tail of the request path in a sub-router or behind
+// http.StripPrefix, so the head of the original request path is kept. The
+// location has no scheme and host, so the client keeps the ones it used.
+func redirectLocation(r *http.Request, routePath, target string) string {
+	var reqPath string
+	if u, err := url.ParseRequestURI(r.RequestURI); err == nil {
+		reqPath = u.EscapedPath()
+	}
+
+	location := target
+	for _, p := range []string{reqPath, r.URL.RawPath, r.URL.Path} {
+		if p != "" && strings.HasSuffix(p, routePath) {
+			location = p[:len(p)-len(routePath)] + target
+			break
+		}
+	}
+
+	if r.URL.RawQuery != "" {
+		location += "?" + r.URL.RawQuery
+	}
+	return location
+}
@@ -0,0 +1,184 @@
+package chi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMuxPathPolicy(t *testing.T) {
+	newRouter := func(policy PathPolicy) *Mux {
+		r := NewRouter()
+		r.PathPolicy(policy)
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) HandlerError {
+			w.Write([]byte("root"))
+			return nil
+		})
+		r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) HandlerError {
+			w.Write([]byte("user " + URLParam(r, "id")))
+			return nil
+		})
+		r.Post("/users", func(w http.ResponseWriter, r *http.Request) HandlerError {
+			w.Write([]byte("create"))
+			return nil
+		})
+		r.Get("/slash/", func(w http.ResponseWriter, r *http.Request) HandlerError {
+			w.Write([]byte("slash"))
+			return nil
+		})
+		r.Route("/api", func(r Router) {
+			r.Get("/ping", func(w http.ResponseWriter, r *http.Request) HandlerError {
+				w.Write([]byte("pong"))
+				return nil
+			})
+		})
+		return r
+	}
+
+	tests := []struct {
+		policy   PathPolicy
+		method   string
+		path     string
+		status   int
+		body     string
+		location string
+	}{
+		{PathStrict, "GET", "/users/1", 200, "user 1", ""},
+		{PathStrict, "GET", "/users/1/", 404, "Not Found\n", ""},
+		{PathStrict, "GET", "/slash/", 200, "slash", ""},
+
+		{PathStrip, "GET", "/users/1/", 200, "user 1", ""},
+		{PathStrip, "GET", "/api/ping/", 200, "pong", ""},
+		{PathStrip, "GET", "/users//1", 404, "Not Found\n", ""},
+		{PathStrip, "GET", "/", 200, "root", ""},
+
+		{PathClean, "GET", "/users//1/", 200, "user 1", ""},
+		{PathClean, "GET", "/users/2/../1", 200, "user 1", ""},
+		{PathClean, "GET", "/api/./ping", 200, "pong", ""},
+
+		{PathRedirectPermanent, "GET", "/users//1/?q=1", 301, "", "/users/1?q=1"},
+		{PathRedirectPermanent, "POST", "/users/", 308, "", "/users"},
+		{PathRedirectPermanent, "GET", "/api//ping", 301, "", "/api/ping"},
+		{PathRedirectPermanent, "GET", "/slash/", 200, "slash", ""},
+		{PathRedirectPermanent, "GET", "/nothing/", 404, "Not Found\n", ""},
+		{PathRedirectTemporary, "GET", "/users/1/", 302, "", "/users/1"},
+		{PathRedirectTemporary, "POST", "/users/", 307, "", "/users"},
+	}
+
+	for _, tt := range tests {
+		r := newRouter(tt.policy)
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		w := httptest.NewRecorder()
+		r.ToHTTPHandler().ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Fatalf("%v %s %s: expecting status %d, got %d", tt.policy, tt.method, tt.path, tt.status, w.Code)
+		}
+		if tt.location != "" {
+			if loc := w.Header().Get("Location"); loc != tt.location {
+				t.Fatalf("%v %s %s: expecting location '%s', got '%s'", tt.policy, tt.method, tt.path, tt.location, loc)
+			}
+		} else if body := w.Body.String(); body != tt.body {
+			t.Fatalf("%v %s %s: expecting body '%s', got '%s'", tt.policy, tt.method, tt.path, tt.body, body)
+		}
+	}
+}
+
+func TestMuxPathPolicyRedirectPrefix(t *testing.T) {
+	api := NewRouter()
+	api.PathPolicy(PathRedirectPermanent)
+	api.Get("/ping", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		w.Write([]byte("pong"))
+		return nil
+	})
+
+	r := NewRouter()
+	r.Mount("/v1", api)
+
+	// The redirect keeps the head of the request path that was stripped
+	// before reaching the sub-router.
+	h := http.StripPrefix("/app", r.ToHTTPHandler())
+	req := httptest.NewRequest("GET", "http://example.com/app/v1/ping/", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != 301 {
+		t.Fatalf("expecting status 301, got %d", w.Code)
+	}
+	if loc := w.Header().Get("Location"); loc != "/app/v1/ping" {
+		t.Fatalf("expecting location '/app/v1/ping', got '%s'", loc)
+	}
+
+	req = httptest.NewRequest("GET", "//evil.example.com/", nil)
+	w = httptest.NewRecorder()
+	r2 := NewRouter()
+	r2.PathPolicy(PathRedirectPermanent)
+	r2.Get("/evil.example.com", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		return nil
+	})
+	r2.ToHTTPHandler().ServeHTTP(w, req)
+	if loc := w.Header().Get("Location"); loc != "/evil.example.com" {
+		t.Fatalf("expecting location '/evil.example.com', got '%s'", loc)
+	}
+}
+
+func TestMuxCaseSensitivity(t *testing.T) {
+	newRouter := func(cs CaseSensitivity) *Mux {
+		r := NewRouter()
+		r.CaseSensitivity(cs)
+		r.Get("/api/users/{name}", func(w http.ResponseWriter, r *http.Request) HandlerError {
+			w.Write([]byte("user " + URLParam(r, "name")))
+			return nil
+		})
+		r.Get("/About", func(w http.ResponseWriter, r *http.Request) HandlerError {
+			w.Write([]byte("About"))
+			return nil
+		})
+		r.Get("/about", func(w http.ResponseWriter, r *http.Request) HandlerError {
+			w.Write([]byte("about"))
+			return nil
+		})
+		r.Route("/Admin", func(r Router) {
+			r.Get("/Settings/{key}", func(w http.ResponseWriter, r *http.Request) HandlerError {
+				w.Write([]byte("setting " + URLParam(r, "key")))
+				return nil
+			})
+		})
+		return r
+	}
+
+	tests := []struct {
+		cs       CaseSensitivity
+		path     string
+		status   int
+		body     string
+		location string
+	}{
+		{CaseInsensitive, "/admin/settings/Theme", 200, "setting Theme", ""},
+
+		{CaseInsensitiveRedirect, "/API/Users/Bob?x=1", 301, "", "/api/users/Bob?x=1"},
+		{CaseInsensitiveRedirect, "/api/users/Bob", 200, "user Bob", ""},
+		{CaseInsensitiveRedirect, "/admin/settings/Theme", 301, "", "/Admin/Settings/Theme"},
+		{CaseInsensitiveRedirect, "/Admin/SETTINGS/Theme", 301, "", "/Admin/Settings/Theme"},
+		{CaseInsensitiveRedirect, "/ABOUT", 301, "", "/About"},
+		{CaseInsensitiveRedirect, "/admin/nothing", 404, "Not Found\n", ""},
+	}
+
+	for _, tt := range tests {
+		r := newRouter(tt.cs)
+		req := httptest.NewRequest("GET", tt.path, nil)
+		w := httptest.NewRecorder()
+		r.ToHTTPHandler().ServeHTTP(w, req)
+
+		if w.Code != tt.status {
+			t.Fatalf("%v %s: expecting status %d, got %d", tt.cs, tt.path, tt.status, w.Code)
+		}
+		if tt.location != "" {
+			if loc := w.Header().Get("Location"); loc != tt.location {
+				t.Fatalf("%v %s: expecting location '%s', got '%s'", tt.cs, tt.path, tt.location, loc)
+			}
+		} else if body := w.Body.String(); body != tt.body {
+			t.Fatalf("%v %s: expecting body '%s', got '%s'", tt.cs, tt.path, tt.body, body)
+		}
+	}
+}
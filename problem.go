@@ -0,0 +1,173 @@
+package chi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Problem is a HandlerError that carries the members of an RFC 7807
+// problem details object. Return it from a handler or middleware to have
+// ProblemErrorHandler render it as `application/problem+json`.
+//
+// Extensions holds any additional members of the problem object. Members
+// with the same name as one of the standard members are ignored when
+// rendering.
+type Problem struct {
+	// Type is a URI reference that identifies the problem type.
+	// It defaults to "about:blank" when rendered.
+	Type string
+
+	// Title is a short, human-readable summary of the problem type.
+	// It defaults to the status text of the status code when rendered.
+	Title string
+
+	// Status is the HTTP status code for this occurrence of the problem.
+	Status int
+
+	// Detail is a human-readable explanation specific to this occurrence
+	// of the problem.
+	Detail string
+
+	// Instance is a URI reference that identifies the specific occurrence
+	// of the problem.
+	Instance string
+
+	// Extensions are additional members of the problem object.
+	Extensions map[string]interface{}
+
+	// Err is the underlying error, if any. It is never rendered.
+	Err error
+}
+
+// StatusCode returns the HTTP status code of the problem, defaulting to
+// 500 Internal Server Error.
+func (p Problem) StatusCode() int {
+	if p.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return p.Status
+}
+
+func (p Problem) Error() string {
+	switch {
+	case p.Detail != "":
+		return p.Detail
+	case p.Title != "":
+		return p.Title
+	case p.Err != nil:
+		return p.Err.Error()
+	default:
+		return http.StatusText(p.StatusCode())
+	}
+}
+
+// Unwrap returns the underlying error of the problem.
+func (p Problem) Unwrap() error {
+	return p.Err
+}
+
+// MarshalJSON encodes the problem as an RFC 7807 problem details object.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+
+	m["type"] = p.Type
+	if p.Type == "" {
+		m["type"] = "about:blank"
+	}
+	m["title"] = p.Title
+	if p.Title == "" {
+		m["title"] = http.StatusText(p.StatusCode())
+	}
+	m["status"] = p.StatusCode()
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	} else {
+		delete(m, "detail")
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	} else {
+		delete(m, "instance")
+	}
+
+	return json.Marshal(m)
+}
+
+// ProblemFromError converts any HandlerError into a Problem. If `err` is or
+// wraps a Problem, that value is returned. Otherwise a Problem is built from
+// the status code and message of `err`.
+func ProblemFromError(err HandlerError) Problem {
+	var p Problem
+	if errors.As(err, &p) {
+		return p
+	}
+
+	p = Problem{Status: err.StatusCode(), Err: err}
+	if s := err.Error(); s != http.StatusText(p.Status) {
+		p.Detail = s
+	}
+	return p
+}
+
+// ProblemErrorHandler is an ErrorHandlerFunc that renders errors as RFC 7807
+// `application/problem+json` responses. Clients that don't accept JSON
+// receive a plain-text response instead, as written by the default error
+// handler.
+//
+// For example,
+//
+//   r := chi.NewRouter()
+//   r.Error(chi.ProblemErrorHandler)
+//   r.Get("/articles/{id}", func(w http.ResponseWriter, r *http.Request) chi.HandlerError {
+//     return chi.Problem{
+//       Type:   "https://example.com/probs/out-of-stock",
+//       Status: http.StatusNotFound,
+//       Detail: "Article is no longer available",
+//     }
+//   })
+func ProblemErrorHandler(err HandlerError, w http.ResponseWriter, r *http.Request) {
+	if !acceptsJSON(r.Header.Get("Accept")) {
+		DefaultErrorHandler(err, w, r)
+		return
+	}
+
+	p := ProblemFromError(err)
+	b, jerr := json.Marshal(p)
+	if jerr != nil {
+		DefaultErrorHandler(err, w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.StatusCode())
+	w.Write(b)
+}
+
+// problemMediaTypes are the media types of a Problem response, see
+// acceptsJSON.
+var problemMediaTypes = []MediaRange{
+	{Type: "application", Subtype: "problem+json", Q: 1},
+	{Type: "application", Subtype: "json", Q: 1},
+}
+
+// acceptsJSON reports whether a JSON response is acceptable for the given
+// Accept header, as `application/problem+json` or `application/json`. An
+// empty header accepts anything.
+func acceptsJSON(accept string) bool {
+	if strings.TrimSpace(accept) == "" {
+		return true
+	}
+	ranges := ParseAccept(accept)
+	for _, mt := range problemMediaTypes {
+		if AcceptQuality(ranges, mt) > 0 {
+			return true
+		}
+	}
+	return false
+}
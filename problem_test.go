@@ -0,0 +1,160 @@
+package chi
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProblemErrorHandler(t *testing.T) {
+	errDB := errors.New("db: connection refused")
+
+	r := NewRouter()
+	r.Error(ProblemErrorHandler)
+	r.Get("/problem", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		return Problem{
+			Type:       "https://example.com/probs/out-of-credit",
+			Title:      "You do not have enough credit.",
+			Status:     http.StatusForbidden,
+			Detail:     "Your current balance is 30, but that costs 50.",
+			Instance:   "/account/12345/msgs/abc",
+			Extensions: map[string]interface{}{"balance": 30, "status": 200},
+			Err:        errDB,
+		}
+	})
+	r.Get("/error", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		return Error{Code: http.StatusConflict}
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	testcases := []struct {
+		Path           string
+		Accept         string
+		ExpectedStatus int
+		ExpectedType   string
+		ExpectedBody   map[string]interface{}
+		ExpectedText   string
+	}{
+		{
+			Path:           "/problem",
+			Accept:         "application/json",
+			ExpectedStatus: 403,
+			ExpectedType:   "application/problem+json",
+			ExpectedBody: map[string]interface{}{
+				"type":     "https://example.com/probs/out-of-credit",
+				"title":    "You do not have enough credit.",
+				"status":   float64(403),
+				"detail":   "Your current balance is 30, but that costs 50.",
+				"instance": "/account/12345/msgs/abc",
+				"balance":  float64(30),
+			},
+		},
+		{
+			Path:           "/error",
+			ExpectedStatus: 409,
+			ExpectedType:   "application/problem+json",
+			ExpectedBody: map[string]interface{}{
+				"type":   "about:blank",
+				"title":  "Conflict",
+				"status": float64(409),
+			},
+		},
+		{
+			Path:           "/nothing-here",
+			Accept:         "application/problem+json",
+			ExpectedStatus: 404,
+			ExpectedType:   "application/problem+json",
+			ExpectedBody: map[string]interface{}{
+				"type":   "about:blank",
+				"title":  "Not Found",
+				"status": float64(404),
+			},
+		},
+		{
+			Path:           "/problem",
+			Accept:         "text/html",
+			ExpectedStatus: 403,
+			ExpectedType:   "text/plain; charset=utf-8",
+			ExpectedText:   "Your current balance is 30, but that costs 50.\n",
+		},
+		{
+			Path:           "/error",
+			Accept:         "text/html, */*;q=0.8",
+			ExpectedStatus: 409,
+			ExpectedType:   "application/problem+json",
+			ExpectedBody: map[string]interface{}{
+				"type":   "about:blank",
+				"title":  "Conflict",
+				"status": float64(409),
+			},
+		},
+		{
+			Path:           "/error",
+			Accept:         "application/json;q=0, text/plain",
+			ExpectedStatus: 409,
+			ExpectedType:   "text/plain; charset=utf-8",
+			ExpectedText:   "Conflict\n",
+		},
+	}
+
+	for _, tc := range testcases {
+		req, _ := http.NewRequest("GET", ts.URL+tc.Path, nil)
+		if tc.Accept != "" {
+			req.Header.Set("Accept", tc.Accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.StatusCode != tc.ExpectedStatus {
+			t.Fatalf("%s: expecting status %d, got %d", tc.Path, tc.ExpectedStatus, resp.StatusCode)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != tc.ExpectedType {
+			t.Fatalf("%s: expecting content type %q, got %q", tc.Path, tc.ExpectedType, ct)
+		}
+
+		if tc.ExpectedBody != nil {
+			var body map[string]interface{}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body) != len(tc.ExpectedBody) {
+				t.Fatalf("%s: expecting %v, got %v", tc.Path, tc.ExpectedBody, body)
+			}
+			for k, v := range tc.ExpectedBody {
+				if body[k] != v {
+					t.Fatalf("%s: expecting %q to be %v, got %v", tc.Path, k, v, body[k])
+				}
+			}
+		} else {
+			b, _ := ioutil.ReadAll(resp.Body)
+			if string(b) != tc.ExpectedText {
+				t.Fatalf("%s: expecting body %q, got %q", tc.Path, tc.ExpectedText, b)
+			}
+		}
+		resp.Body.Close()
+	}
+}
+
+func TestProblemFromError(t *testing.T) {
+	errDB := errors.New("db: connection refused")
+
+	p := ProblemFromError(Error{Code: http.StatusBadGateway, Err: errDB})
+	if p.StatusCode() != http.StatusBadGateway || p.Detail != errDB.Error() {
+		t.Fatalf("unexpected problem %#v", p)
+	}
+	if !errors.Is(p, errDB) {
+		t.Fatal("expecting problem to wrap the original error")
+	}
+
+	orig := Problem{Status: http.StatusTeapot, Title: "Short and stout"}
+	if p := ProblemFromError(orig); p.Title != orig.Title || p.Status != orig.Status {
+		t.Fatalf("expecting problem to be returned as is, got %#v", p)
+	}
+}
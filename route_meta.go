@@ -0,0 +1,42 @@
+package chi
+
+import "net/http"
+
+// Meta is the metadata of a route, such as its auth scopes, rate-limit
+// class or owner team, registered with WithMeta.
+type Meta map[string]interface{}
+
+// merge returns the metadata of `m` overridden by `other`. Neither map is
+// modified, as metadata is shared between the routes and requests.
+func (m Meta) merge(other Meta) Meta {
+	if len(other) == 0 {
+		return m
+	}
+	if len(m) == 0 {
+		return other
+	}
+	merged := make(Meta, len(m)+len(other))
+	for k, v := range m {
+		merged[k] = v
+	}
+	for k, v := range other {
+		merged[k] = v
+	}
+	return merged
+}
+
+// RouteMeta returns the metadata value `key` of the route matching the
+// request, or nil if there's none.
+func RouteMeta(r *http.Request, key string) interface{} {
+	if rctx := RouteContext(r.Context()); rctx != nil {
+		return rctx.RouteMeta(key)
+	}
+	return nil
+}
+
+// RouteMeta returns the metadata value `key` of the route matching the
+// request. The metadata of a mounted sub-router's route overrides the
+// metadata of the mount.
+func (x *Context) RouteMeta(key string) interface{} {
+	return x.routeMeta[key]
+}
@@ -0,0 +1,70 @@
+package chi
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteMeta(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) HandlerError {
+		fmt.Fprintf(w, "%v %v %v", RouteMeta(r, "team"), RouteMeta(r, "scope"), RouteMeta(r, "format"))
+		return nil
+	}
+
+	r := NewRouter()
+	r.Get("/", h)
+	r.WithMeta(Meta{"team": "stale"}).Get("/again", h)
+	r.Get("/again", h)
+	r.WithMeta(Meta{"team": "core"}).Route("/articles", func(r Router) {
+		r.Get("/", h)
+		admin := r.WithMeta(Meta{"scope": "articles:write"})
+		admin.Post("/", h)
+		admin.WithMeta(Meta{"team": "editorial"}).Delete("/{id}", h)
+		admin.When(ContentType("application/xml")).WithMeta(Meta{"format": "xml"}).Put("/{id}", h)
+		admin.Put("/{id}", h)
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	tests := []struct {
+		method, path string
+		body         string
+	}{
+		{"GET", "/", "<nil> <nil> <nil>"},
+		{"GET", "/articles", "core <nil> <nil>"},
+		{"POST", "/articles", "core articles:write <nil>"},
+		{"DELETE", "/articles/1", "editorial articles:write <nil>"},
+		{"PUT", "/articles/1", "core articles:write <nil>"},
+		{"GET", "/again", "<nil> <nil> <nil>"},
+	}
+	for _, tt := range tests {
+		if _, body := testRequest(t, ts, tt.method, tt.path, nil); body != tt.body {
+			t.Fatalf("%s %s: expecting '%s', got '%s'", tt.method, tt.path, tt.body, body)
+		}
+	}
+
+	req, _ := http.NewRequest("PUT", ts.URL+"/articles/1", nil)
+	req.Header.Set("Content-Type", "application/xml")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "core articles:write xml" {
+		t.Fatalf("PUT /articles/1 as xml: expecting 'core articles:write xml', got '%s'", body)
+	}
+
+	teams := map[string]interface{}{}
+	WalkMeta(r, func(method string, route string, handler Handler, meta Meta, middlewares ...func(Handler) Handler) error {
+		teams[method+" "+route] = meta["team"]
+		return nil
+	})
+	if teams["GET /"] != nil || teams["GET /articles/"] != "core" || teams["DELETE /articles/{id}"] != "editorial" {
+		t.Fatalf("unexpected route metadata %v", teams)
+	}
+}
@@ -0,0 +1,149 @@
+package chi
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// SpanKind is the stage of serving a request traced by a Tracer.
+type SpanKind int
+
+const (
+	// SpanRouting spans serving the request by the root Mux, including its
+	// middlewares. Its name is the request method, as the routing pattern
+	// is only complete once the span ends.
+	SpanRouting SpanKind = iota
+
+	// SpanMiddleware spans a middleware of a Mux or an inline-Mux, named by
+	// the middleware function, such as "middleware.Logger".
+	SpanMiddleware
+
+	// SpanMount spans the traversal of a mounted sub-router, named by the
+	// routing pattern of the mount, such as "/api/*".
+	SpanMount
+
+	// SpanHandler spans the handler of the route, including its inline
+	// middlewares, named by the routing pattern, such as "/users/{id}".
+	SpanHandler
+)
+
+// String returns the name of the span kind.
+func (k SpanKind) String() string {
+	switch k {
+	case SpanRouting:
+		return "routing"
+	case SpanMiddleware:
+		return "middleware"
+	case SpanMount:
+		return "mount"
+	case SpanHandler:
+		return "handler"
+	}
+	return ""
+}
+
+// Tracer receives the start and end of the spans of serving a request, see
+// Mux.Tracer. Start returns the request passed on to the span, typically
+// with a context carrying the span, and End is called with that request and
+// the error returned by the span, before any error handler responds to it.
+// Spans are nested, ending in the reverse order they started.
+//
+// An OpenTelemetry tracer maps on it as starting a span from the request
+// context in Start, and ending the span of the request context in End,
+// renamed by Context.RoutePattern and with the status of the error.
+type Tracer interface {
+	Start(r *http.Request, kind SpanKind, name string) *http.Request
+	End(r *http.Request, kind SpanKind, err HandlerError)
+}
+
+// Tracer sets the Tracer of the requests served by the Mux as the root
+// router. The Tracer of a sub-router is ignored, as the spans of a request
+// are traced by the Tracer of the router it's served by.
+func (mx *Mux) Tracer(t Tracer) {
+	m := mx
+	if mx.inline && mx.parent != nil {
+		m = mx.parent
+	}
+	m.tracer = t
+}
+
+// trace serves the request with `h` in a span of the Tracer of the routing
+// context, if it has one.
+func (x *Context) trace(w http.ResponseWriter, r *http.Request, kind SpanKind, name string, h Handler) (err HandlerError) {
+	if x == nil || x.tracer == nil {
+		return h.ServeHTTP(w, r)
+	}
+	tracer := x.tracer
+	r = tracer.Start(r, kind, name)
+	defer func() {
+		if rvr := recover(); rvr != nil {
+			tracer.End(r, kind, Error{Code: http.StatusInternalServerError})
+			panic(rvr)
+		}
+		tracer.End(r, kind, err)
+	}()
+	return h.ServeHTTP(w, r)
+}
+
+// serveRoute serves the request with the handler `h` of the matched route or
+// mounted sub-router, in a span of the Tracer of the routing context if it
+// has one.
+func (x *Context) serveRoute(w http.ResponseWriter, r *http.Request, kind SpanKind, h Handler) HandlerError {
+	if c, ok := h.(*ChainHandler); ok {
+		h = c.handler(x)
+	}
+	if x.tracer == nil {
+		return h.ServeHTTP(w, r)
+	}
+	return x.trace(w, r, kind, x.RoutePattern(), h)
+}
+
+// tracedChain is a middleware chain with each middleware in a span, built
+// once the first traced request is served by the chain, so that requests
+// without a Tracer don't pay for the spans.
+type tracedChain struct {
+	once sync.Once
+	h    Handler
+}
+
+// handler returns the traced chain of `middlewares` and `endpoint`.
+func (tc *tracedChain) handler(middlewares []func(Handler) Handler, endpoint Handler) Handler {
+	tc.once.Do(func() {
+		if len(middlewares) == 0 {
+			tc.h = endpoint
+			return
+		}
+		h := captureErrorRequest(endpoint)
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			h = traceMiddleware(middlewares[i], captureErrorRequest(middlewares[i](h)))
+		}
+		tc.h = h
+	})
+	return tc.h
+}
+
+// traceMiddleware wraps the handler `h` returned by the middleware `mw` in a
+// span of the Tracer of the routing context.
+func traceMiddleware(mw func(Handler) Handler, h Handler) Handler {
+	name := middlewareName(mw)
+	return HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError {
+		return RouteContext(r.Context()).trace(w, r, SpanMiddleware, name, h)
+	})
+}
+
+// middlewareName returns the name of the function `mw` without its package
+// path, such as "middleware.Logger".
+func middlewareName(mw func(Handler) Handler) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(mw).Pointer())
+	if fn == nil {
+		return ""
+	}
+	name := fn.Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
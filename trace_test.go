@@ -0,0 +1,83 @@
+package chi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testTracer struct {
+	events []string
+}
+
+type testSpanCtxKey struct{}
+
+func (t *testTracer) Start(r *http.Request, kind SpanKind, name string) *http.Request {
+	t.events = append(t.events, fmt.Sprintf("start %s %s", kind, name))
+	return r.WithContext(context.WithValue(r.Context(), testSpanCtxKey{}, name))
+}
+
+func (t *testTracer) End(r *http.Request, kind SpanKind, err HandlerError) {
+	status := 0
+	if err != nil {
+		status = err.StatusCode()
+	}
+	t.events = append(t.events, fmt.Sprintf("end %s %s %d", kind, r.Context().Value(testSpanCtxKey{}), status))
+}
+
+func testTraceMiddleware(next Handler) Handler {
+	return next
+}
+
+func TestMuxTracer(t *testing.T) {
+	tracer := &testTracer{}
+
+	r := NewRouter()
+	r.Tracer(tracer)
+	r.Use(testTraceMiddleware)
+	r.Route("/users", func(r Router) {
+		r.With(testTraceMiddleware).Get("/{id}", func(w http.ResponseWriter, r *http.Request) HandlerError {
+			if r.Context().Value(testSpanCtxKey{}) != "chi.testTraceMiddleware" {
+				t.Errorf("expecting the request of the innermost span, got %v", r.Context().Value(testSpanCtxKey{}))
+			}
+			return Error{Code: http.StatusTeapot}
+		})
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	if resp, _ := testRequest(t, ts, "GET", "/users/1", nil); resp.StatusCode != http.StatusTeapot {
+		t.Fatalf("expecting 418, got %d", resp.StatusCode)
+	}
+
+	expected := []string{
+		"start routing GET",
+		"start middleware chi.testTraceMiddleware",
+		"start mount /users/*",
+		"start handler /users/{id}",
+		"start middleware chi.testTraceMiddleware",
+		"end middleware chi.testTraceMiddleware 418",
+		"end handler /users/{id} 418",
+		"end mount /users/* 418",
+		"end middleware chi.testTraceMiddleware 418",
+		"end routing GET 418",
+	}
+	if got := strings.Join(tracer.events, "\n"); got != strings.Join(expected, "\n") {
+		t.Fatalf("unexpected spans:\n%s", got)
+	}
+
+	// Sub-routers don't trace requests on their own
+	tracer.events = nil
+	sub := NewRouter()
+	sub.Tracer(tracer)
+	sub.Get("/", func(w http.ResponseWriter, r *http.Request) HandlerError { return nil })
+	r.Mount("/sub", sub)
+	testRequest(t, ts, "GET", "/sub/", nil)
+	if len(tracer.events) == 0 || tracer.events[0] != "start routing GET" || strings.Count(strings.Join(tracer.events, "\n"), "routing") != 2 {
+		t.Fatalf("unexpected spans:\n%s", strings.Join(tracer.events, "\n"))
+	}
+}
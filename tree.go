@@ -12,6 +12,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 type methodTyp int
@@ -88,6 +89,9 @@ type node struct {
 	// regexp matcher for regexp nodes
 	rex *regexp.Regexp
 
+	// named matcher for regexp nodes, see RegisterParamMatcher
+	matcher func(value string) bool
+
 	// HTTP handler endpoints on the leaf node
 	endpoints endpoints
 
@@ -107,6 +111,12 @@ type endpoint struct {
 	// endpoint handler
 	handler Handler
 
+	// conditional handlers, matched on the request before the handler
+	conditional []conditionalEndpoint
+
+	// metadata of the endpoint handler
+	meta Meta
+
 	// pattern is the routing pattern for handler nodes
 	pattern string
 
@@ -123,7 +133,69 @@ func (s endpoints) Value(method methodTyp) *endpoint {
 	return mh
 }
 
+// routable reports whether the endpoint has a handler or conditional
+// handlers.
+func (e *endpoint) routable() bool {
+	return e != nil && (e.handler != nil || len(e.conditional) > 0)
+}
+
+// route returns the handler of the endpoint, or the endpoint itself to match
+// the conditional handlers on the request.
+func (e *endpoint) route() Handler {
+	if len(e.conditional) == 0 {
+		return e.handler
+	}
+	return e
+}
+
+// routeMeta returns the metadata of the route of the endpoint, see
+// Route.Meta.
+func (e *endpoint) routeMeta() Meta {
+	if e.handler == nil && len(e.conditional) > 0 {
+		return e.conditional[0].meta
+	}
+	return e.meta
+}
+
+// handlers returns the handlers of the endpoint with their metadata, the
+// unconditional handler first, followed by the conditional handlers in the
+// order they're matched.
+func (e *endpoint) handlers() []conditionalEndpoint {
+	var hs []conditionalEndpoint
+	if e.handler != nil {
+		hs = append(hs, conditionalEndpoint{handler: e.handler, meta: e.meta})
+	}
+	return append(hs, e.conditional...)
+}
+
+// find returns the endpoint of `method`. With `autoHead` set, HEAD falls back
+// to the GET endpoint if there's no HEAD handler.
+func (s endpoints) find(method methodTyp, autoHead bool) *endpoint {
+	h := s[method]
+	if !h.routable() && method == mHEAD && autoHead {
+		h = s[mGET]
+	}
+	return h
+}
+
+// methods returns the methods that have a handler among the endpoints.
+func (s endpoints) methods() methodTyp {
+	var methods methodTyp
+	for mt, h := range s {
+		if mt != mSTUB && mt != mALL && h.routable() {
+			methods |= mt
+		}
+	}
+	return methods
+}
+
 func (n *node) InsertRoute(method methodTyp, pattern string, handler Handler) *node {
+	return n.insertRoute(method, pattern, handler, nil, nil)
+}
+
+// insertRoute adds the route to the tree with the metadata `meta`, as a
+// conditional endpoint if there are `conditions`.
+func (n *node) insertRoute(method methodTyp, pattern string, handler Handler, conditions []RouteCondition, meta Meta) *node {
 	var parent *node
 	search := pattern
 
@@ -131,7 +203,7 @@ func (n *node) InsertRoute(method methodTyp, pattern string, handler Handler) *n
 		// Handle key exhaustion
 		if len(search) == 0 {
 			// Insert or update the node's leaf handler
-			n.setEndpoint(method, handler, pattern)
+			n.setEndpoint(method, handler, pattern, conditions, meta)
 			return n
 		}
 
@@ -159,7 +231,7 @@ func (n *node) InsertRoute(method methodTyp, pattern string, handler Handler) *n
 		if n == nil {
 			child := &node{label: label, tail: segTail, prefix: search}
 			hn := parent.addChild(child, search)
-			hn.setEndpoint(method, handler, pattern)
+			hn.setEndpoint(method, handler, pattern, conditions, meta)
 
 			return hn
 		}
@@ -199,7 +271,7 @@ func (n *node) InsertRoute(method methodTyp, pattern string, handler Handler) *n
 		// If the new key is a subset, set the method/handler on this node and finish.
 		search = search[commonPrefix:]
 		if len(search) == 0 {
-			child.setEndpoint(method, handler, pattern)
+			child.setEndpoint(method, handler, pattern, conditions, meta)
 			return child
 		}
 
@@ -210,7 +282,7 @@ func (n *node) InsertRoute(method methodTyp, pattern string, handler Handler) *n
 			prefix: search,
 		}
 		hn := child.addChild(subchild, search)
-		hn.setEndpoint(method, handler, pattern)
+		hn.setEndpoint(method, handler, pattern, conditions, meta)
 		return hn
 	}
 }
@@ -240,12 +312,17 @@ func (n *node) addChild(child *node, prefix string) *node {
 		// Search prefix contains a param, regexp or wildcard
 
 		if segTyp == ntRegexp {
-			rex, err := regexp.Compile(segRexpat)
-			if err != nil {
-				panic(fmt.Sprintf("chi: invalid regexp pattern '%s' in route param", segRexpat))
+			if matcher, ok := paramMatcher(segRexpat); ok {
+				child.prefix = segRexpat
+				child.matcher = matcher
+			} else {
+				rex, err := compileParamRegexp(segRexpat)
+				if err != nil {
+					panic(fmt.Sprintf("chi: invalid regexp pattern '%s' in route param", segRexpat))
+				}
+				child.prefix = segRexpat
+				child.rex = rex
 			}
-			child.prefix = segRexpat
-			child.rex = rex
 		}
 
 		if segStartIdx == 0 {
@@ -284,6 +361,7 @@ func (n *node) addChild(child *node, prefix string) *node {
 			child.typ = ntStatic
 			child.prefix = search[:segStartIdx]
 			child.rex = nil
+			child.matcher = nil
 
 			// add the param edge node
 			search = search[segStartIdx:]
@@ -328,39 +406,42 @@ func (n *node) getEdge(ntyp nodeTyp, label, tail byte, prefix string) *node {
 	return nil
 }
 
-func (n *node) setEndpoint(method methodTyp, handler Handler, pattern string) {
+func (n *node) setEndpoint(method methodTyp, handler Handler, pattern string, conditions []RouteCondition, meta Meta) {
 	// Set the handler for the method type on the node
 	if n.endpoints == nil {
 		n.endpoints = make(endpoints)
 	}
 
 	paramKeys := patParamKeys(pattern)
+	set := func(h *endpoint) {
+		h.pattern = pattern
+		h.paramKeys = paramKeys
+		if len(conditions) > 0 {
+			// Conditional endpoints are matched in the order they're added
+			h.conditional = append(h.conditional, conditionalEndpoint{conditions, handler, meta})
+		} else {
+			h.handler = handler
+			h.meta = meta
+		}
+	}
 
 	if method&mSTUB == mSTUB {
 		n.endpoints.Value(mSTUB).handler = handler
 	}
 	if method&mALL == mALL {
-		h := n.endpoints.Value(mALL)
-		h.handler = handler
-		h.pattern = pattern
-		h.paramKeys = paramKeys
+		set(n.endpoints.Value(mALL))
 		for _, m := range methodMap {
-			h := n.endpoints.Value(m)
-			h.handler = handler
-			h.pattern = pattern
-			h.paramKeys = paramKeys
+			set(n.endpoints.Value(m))
 		}
 	} else {
-		h := n.endpoints.Value(method)
-		h.handler = handler
-		h.pattern = pattern
-		h.paramKeys = paramKeys
+		set(n.endpoints.Value(method))
 	}
 }
 
 func (n *node) FindRoute(rctx *Context, method methodTyp, path string) (*node, endpoints, Handler) {
 	// Reset the context routing pattern and params
 	rctx.routePattern = ""
+	rctx.caseFolded = false
 	rctx.routeParams.Keys = rctx.routeParams.Keys[:0]
 	rctx.routeParams.Values = rctx.routeParams.Values[:0]
 
@@ -375,12 +456,17 @@ func (n *node) FindRoute(rctx *Context, method methodTyp, path string) (*node, e
 	rctx.URLParams.Values = append(rctx.URLParams.Values, rctx.routeParams.Values...)
 
 	// Record the routing pattern in the request lifecycle
-	if rn.endpoints[method].pattern != "" {
-		rctx.routePattern = rn.endpoints[method].pattern
+	ep := rn.endpoints.find(method, rctx.autoHead)
+	if ep.pattern != "" {
+		rctx.routePattern = ep.pattern
 		rctx.RoutePatterns = append(rctx.RoutePatterns, rctx.routePattern)
 	}
+	if len(ep.conditional) == 0 {
+		// The metadata of conditional endpoints is recorded once matched
+		rctx.routeMeta = rctx.routeMeta.merge(ep.meta)
+	}
 
-	return rn, rn.endpoints, rn.endpoints[method].handler
+	return rn, rn.endpoints, ep.route()
 }
 
 // Recursive edge traversal by checking all nodeTyp groups along the way.
@@ -405,6 +491,12 @@ func (n *node) findRoute(rctx *Context, method methodTyp, path string) *node {
 
 		switch ntyp {
 		case ntStatic:
+			if rctx.caseInsensitive {
+				if fin := nn.findRouteFold(rctx, method, nds, xsearch); fin != nil {
+					return fin
+				}
+				continue
+			}
 			xn = nds.findEdge(label)
 			if xn == nil || !strings.HasPrefix(xsearch, xn.prefix) {
 				continue
@@ -432,7 +524,11 @@ func (n *node) findRoute(rctx *Context, method methodTyp, path string) *node {
 					}
 				}
 
-				if ntyp == ntRegexp && xn.rex != nil {
+				if ntyp == ntRegexp && xn.matcher != nil {
+					if strings.IndexByte(xsearch[:p], '/') != -1 || !xn.matcher(xsearch[:p]) {
+						continue
+					}
+				} else if ntyp == ntRegexp && xn.rex != nil {
 					if !xn.rex.MatchString(xsearch[:p]) {
 						continue
 					}
@@ -447,8 +543,8 @@ func (n *node) findRoute(rctx *Context, method methodTyp, path string) *node {
 
 				if len(xsearch) == 0 {
 					if xn.isLeaf() {
-						h := xn.endpoints[method]
-						if h != nil && h.handler != nil {
+						h := xn.endpoints.find(method, rctx.autoHead)
+						if h.routable() {
 							rctx.routeParams.Keys = append(rctx.routeParams.Keys, h.paramKeys...)
 							return xn
 						}
@@ -456,6 +552,7 @@ func (n *node) findRoute(rctx *Context, method methodTyp, path string) *node {
 						// flag that the routing context found a route, but not a corresponding
 						// supported method
 						rctx.methodNotAllowed = true
+						rctx.methodsAllowed |= xn.endpoints.methods()
 					}
 				}
 
@@ -486,8 +583,8 @@ func (n *node) findRoute(rctx *Context, method methodTyp, path string) *node {
 		// did we find it yet?
 		if len(xsearch) == 0 {
 			if xn.isLeaf() {
-				h := xn.endpoints[method]
-				if h != nil && h.handler != nil {
+				h := xn.endpoints.find(method, rctx.autoHead)
+				if h.routable() {
 					rctx.routeParams.Keys = append(rctx.routeParams.Keys, h.paramKeys...)
 					return xn
 				}
@@ -495,6 +592,7 @@ func (n *node) findRoute(rctx *Context, method methodTyp, path string) *node {
 				// flag that the routing context found a route, but not a corresponding
 				// supported method
 				rctx.methodNotAllowed = true
+				rctx.methodsAllowed |= xn.endpoints.methods()
 			}
 		}
 
@@ -516,6 +614,64 @@ func (n *node) findRoute(rctx *Context, method methodTyp, path string) *node {
 	return nil
 }
 
+// findRouteFold continues the search at the static nodes `nds` with a prefix
+// matching the start of `search` under ASCII case folding. The node matching
+// in the exact case is searched first.
+func (n *node) findRouteFold(rctx *Context, method methodTyp, nds nodes, search string) *node {
+	lower, upper := search[0], search[0]
+	if 'A' <= lower && lower <= 'Z' {
+		lower += 'a' - 'A'
+	} else if 'a' <= upper && upper <= 'z' {
+		upper -= 'a' - 'A'
+	}
+
+	var candidates [2]*node
+	for _, label := range [2]byte{lower, upper} {
+		xn := nds.findEdge(label)
+		if xn == nil || len(search) < len(xn.prefix) || !strings.EqualFold(search[:len(xn.prefix)], xn.prefix) {
+			continue
+		}
+		if strings.HasPrefix(search, xn.prefix) {
+			candidates[0], candidates[1] = xn, candidates[0]
+		} else if candidates[0] == nil {
+			candidates[0] = xn
+		} else {
+			candidates[1] = xn
+		}
+		if lower == upper {
+			break
+		}
+	}
+
+	for _, xn := range candidates {
+		if xn == nil {
+			continue
+		}
+		folded := !strings.HasPrefix(search, xn.prefix)
+		xsearch := search[len(xn.prefix):]
+
+		if len(xsearch) == 0 && xn.isLeaf() {
+			h := xn.endpoints.find(method, rctx.autoHead)
+			if h.routable() {
+				rctx.caseFolded = rctx.caseFolded || folded
+				rctx.routeParams.Keys = append(rctx.routeParams.Keys, h.paramKeys...)
+				return xn
+			}
+
+			// flag that the routing context found a route, but not a corresponding
+			// supported method
+			rctx.methodNotAllowed = true
+			rctx.methodsAllowed |= xn.endpoints.methods()
+		}
+
+		if fin := xn.findRoute(rctx, method, xsearch); fin != nil {
+			rctx.caseFolded = rctx.caseFolded || folded
+			return fin
+		}
+	}
+	return nil
+}
+
 func (n *node) findEdge(ntyp nodeTyp, label byte) *node {
 	nds := n.children[ntyp]
 	num := len(nds)
@@ -594,8 +750,12 @@ func (n *node) routes() []Route {
 	rts := []Route{}
 
 	n.walk(func(eps endpoints, subroutes Routes) bool {
-		if eps[mSTUB] != nil && eps[mSTUB].handler != nil && subroutes == nil {
-			return false
+		if eps[mSTUB] != nil && eps[mSTUB].handler != nil {
+			// Only list the wildcard node of a mounted sub-router, skipping
+			// the stubs for the mount point itself.
+			if subroutes == nil || eps[mALL] == nil || !strings.HasSuffix(eps[mALL].pattern, "*") {
+				return false
+			}
 		}
 
 		// Group methodHandlers by unique patterns
@@ -615,22 +775,29 @@ func (n *node) routes() []Route {
 
 		for p, mh := range pats {
 			hs := make(map[string]Handler)
-			if mh[mALL] != nil && mh[mALL].handler != nil {
-				hs["*"] = mh[mALL].handler
+			meta := make(map[string]Meta)
+			if mh[mALL].routable() {
+				hs["*"] = mh[mALL].route()
+				if hm := mh[mALL].routeMeta(); len(hm) > 0 {
+					meta["*"] = hm
+				}
 			}
 
 			for mt, h := range mh {
-				if h.handler == nil {
+				if !h.routable() {
 					continue
 				}
 				m := methodTypString(mt)
 				if m == "" {
 					continue
 				}
-				hs[m] = h.handler
+				hs[m] = h.route()
+				if hm := h.routeMeta(); len(hm) > 0 {
+					meta[m] = hm
+				}
 			}
 
-			rt := Route{p, hs, subroutes}
+			rt := Route{p, hs, subroutes, meta}
 			rts = append(rts, rt)
 		}
 
@@ -710,7 +877,8 @@ func patNextSegment(pattern string) (nodeTyp, string, string, byte, int, int) {
 			key = key[:idx]
 		}
 
-		if len(rexpat) > 0 {
+		// Named matchers are kept as they are, see RegisterParamMatcher
+		if _, named := paramMatcher(rexpat); !named && len(rexpat) > 0 {
 			if rexpat[0] != '^' {
 				rexpat = "^" + rexpat
 			}
@@ -729,6 +897,24 @@ func patNextSegment(pattern string) (nodeTyp, string, string, byte, int, int) {
 	return ntCatchAll, "*", "", 0, ws, len(pattern)
 }
 
+// expandPattern returns the routing pattern `pattern` with its params and
+// wildcard replaced by `values`, in order.
+func expandPattern(pattern string, values []string) string {
+	var b strings.Builder
+	for i := 0; ; i++ {
+		typ, _, _, _, start, end := patNextSegment(pattern)
+		if typ == ntStatic {
+			b.WriteString(pattern)
+			return b.String()
+		}
+		b.WriteString(pattern[:start])
+		if i < len(values) {
+			b.WriteString(values[i])
+		}
+		pattern = pattern[end:]
+	}
+}
+
 func patParamKeys(pattern string) []string {
 	pat := pattern
 	paramKeys := []string{}
@@ -763,6 +949,18 @@ func longestPrefix(k1, k2 string) int {
 	return i
 }
 
+// methodTypStrings returns the sorted names of the methods set in `methods`.
+func methodTypStrings(methods methodTyp) []string {
+	var s []string
+	for name, t := range methodMap {
+		if methods&t == t {
+			s = append(s, name)
+		}
+	}
+	sort.Strings(s)
+	return s
+}
+
 func methodTypString(method methodTyp) string {
 	for s, t := range methodMap {
 		if method == t {
@@ -812,29 +1010,52 @@ func (ns nodes) findEdge(label byte) *node {
 }
 
 // Route describes the details of a routing handler.
-// Handlers map key is an HTTP method
+// Handlers and Meta map key is an HTTP method
 type Route struct {
 	Pattern   string
 	Handlers  map[string]Handler
 	SubRoutes Routes
+
+	// Meta is the metadata of the handlers registered with WithMeta. For a
+	// conditional route, whose handler matches its conditional handlers on
+	// the request, it's the metadata of its unconditional handler, or of its
+	// first conditional handler without one. Walk visits the handlers of a
+	// conditional route with their own metadata.
+	Meta map[string]Meta
 }
 
 // WalkFunc is the type of the function called for each method and route visited by Walk.
 type WalkFunc func(method string, route string, handler Handler, middlewares ...func(Handler) Handler) error
 
-// Walk walks any router tree that implements Routes interface.
+// WalkMetaFunc is the type of the function called for each method and route
+// visited by WalkMeta. The metadata of a route includes the metadata of the
+// mounts leading to it.
+type WalkMetaFunc func(method string, route string, handler Handler, meta Meta, middlewares ...func(Handler) Handler) error
+
+// Walk walks any router tree that implements Routes interface. A route with
+// conditional handlers registered with Mux.When is visited once for each of
+// its handlers, the unconditional handler first.
 func Walk(r Routes, walkFn WalkFunc) error {
-	return walk(r, walkFn, "")
+	return walk(r, func(method string, route string, handler Handler, _ Meta, middlewares ...func(Handler) Handler) error {
+		return walkFn(method, route, handler, middlewares...)
+	}, "", nil)
+}
+
+// WalkMeta walks any router tree that implements Routes interface like Walk,
+// passing the metadata of each route to `walkFn`.
+func WalkMeta(r Routes, walkFn WalkMetaFunc) error {
+	return walk(r, walkFn, "", nil)
 }
 
-func walk(r Routes, walkFn WalkFunc, parentRoute string, parentMw ...func(Handler) Handler) error {
+func walk(r Routes, walkFn WalkMetaFunc, parentRoute string, parentMeta Meta, parentMw ...func(Handler) Handler) error {
 	for _, route := range r.Routes() {
 		mws := make([]func(Handler) Handler, len(parentMw))
 		copy(mws, parentMw)
 		mws = append(mws, r.Middlewares()...)
 
 		if route.SubRoutes != nil {
-			if err := walk(route.SubRoutes, walkFn, parentRoute+route.Pattern, mws...); err != nil {
+			meta := parentMeta.merge(route.Meta["*"])
+			if err := walk(route.SubRoutes, walkFn, parentRoute+route.Pattern, meta, mws...); err != nil {
 				return err
 			}
 			continue
@@ -849,13 +1070,22 @@ func walk(r Routes, walkFn WalkFunc, parentRoute string, parentMw ...func(Handle
 			fullRoute := parentRoute + route.Pattern
 			fullRoute = strings.Replace(fullRoute, "/*/", "/", -1)
 
-			if chain, ok := handler.(*ChainHandler); ok {
-				if err := walkFn(method, fullRoute, chain.Endpoint, append(mws, chain.Middlewares...)...); err != nil {
-					return err
-				}
-			} else {
-				if err := walkFn(method, fullRoute, handler, mws...); err != nil {
-					return err
+			// A conditional route is visited once for each of its handlers
+			handlers := []conditionalEndpoint{{handler: handler, meta: route.Meta[method]}}
+			if ep, ok := handler.(*endpoint); ok {
+				handlers = ep.handlers()
+			}
+
+			for _, h := range handlers {
+				meta := parentMeta.merge(h.meta)
+				if chain, ok := h.handler.(*ChainHandler); ok {
+					if err := walkFn(method, fullRoute, chain.Endpoint, meta, append(mws, chain.Middlewares...)...); err != nil {
+						return err
+					}
+				} else {
+					if err := walkFn(method, fullRoute, h.handler, meta, mws...); err != nil {
+						return err
+					}
 				}
 			}
 		}
@@ -863,3 +1093,21 @@ func walk(r Routes, walkFn WalkFunc, parentRoute string, parentMw ...func(Handle
 
 	return nil
 }
+
+// paramRegexps are the compiled regexps of URL params by their pattern, so
+// that URLFor reuses the regexps compiled when the routes were added.
+var paramRegexps sync.Map
+
+// compileParamRegexp returns the compiled regexp `rexpat` of a URL param,
+// compiling it only once.
+func compileParamRegexp(rexpat string) (*regexp.Regexp, error) {
+	if rex, ok := paramRegexps.Load(rexpat); ok {
+		return rex.(*regexp.Regexp), nil
+	}
+	rex, err := regexp.Compile(rexpat)
+	if err != nil {
+		return nil, err
+	}
+	paramRegexps.Store(rexpat, rex)
+	return rex, nil
+}
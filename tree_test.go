@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -409,6 +410,173 @@ func TestTreeRegexMatchWholeParam(t *testing.T) {
 	}
 }
 
+func TestTreeParamMatchers(t *testing.T) {
+	hStub1 := HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError { return nil })
+	hStub2 := HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError { return nil })
+	hStub3 := HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError { return nil })
+	hStub4 := HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError { return nil })
+	hStub5 := HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError { return nil })
+	hStub6 := HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError { return nil })
+
+	RegisterParamMatcher("even", func(s string) bool {
+		return matchUint(s) && (s[len(s)-1]-'0')%2 == 0
+	})
+
+	tr := &node{}
+	tr.InsertRoute(mGET, "/users/{id:int}", hStub1)
+	tr.InsertRoute(mGET, "/users/{name:alpha}", hStub2)
+	tr.InsertRoute(mGET, "/items/{id:uuid}", hStub3)
+	tr.InsertRoute(mGET, "/reports/{day:date}.{format:alnum}", hStub4)
+	tr.InsertRoute(mGET, "/pages/{n:even}", hStub5)
+	tr.InsertRoute(mGET, "/pages/{n:uint}", hStub6)
+
+	tests := []struct {
+		r string   // input request path
+		h Handler  // output matched handler
+		k []string // output param keys
+		v []string // output param values
+	}{
+		{r: "/users/-42", h: hStub1, k: []string{"id"}, v: []string{"-42"}},
+		{r: "/users/Bob", h: hStub2, k: []string{"name"}, v: []string{"Bob"}},
+		{r: "/users/Bob42", h: nil, k: []string{}, v: []string{}},
+		{r: "/items/f47ac10b-58cc-4372-a567-0e02b2c3d479", h: hStub3, k: []string{"id"}, v: []string{"f47ac10b-58cc-4372-a567-0e02b2c3d479"}},
+		{r: "/items/f47ac10b", h: nil, k: []string{}, v: []string{}},
+		{r: "/reports/2020-02-29.csv", h: hStub4, k: []string{"day", "format"}, v: []string{"2020-02-29", "csv"}},
+		{r: "/reports/2020-13-01.csv", h: nil, k: []string{}, v: []string{}},
+		{r: "/pages/12", h: hStub5, k: []string{"n"}, v: []string{"12"}},
+		{r: "/pages/13", h: hStub6, k: []string{"n"}, v: []string{"13"}},
+	}
+
+	for i, tt := range tests {
+		rctx := NewRouteContext()
+
+		_, handlers, _ := tr.FindRoute(rctx, mGET, tt.r)
+
+		var handler Handler
+		if methodHandler, ok := handlers[mGET]; ok {
+			handler = methodHandler.handler
+		}
+
+		paramKeys := rctx.routeParams.Keys
+		paramValues := rctx.routeParams.Values
+
+		if fmt.Sprintf("%v", tt.h) != fmt.Sprintf("%v", handler) {
+			t.Errorf("input [%d]: find '%s' expecting handler:%v , got:%v", i, tt.r, tt.h, handler)
+		}
+		if !stringSliceEqual(tt.k, paramKeys) {
+			t.Errorf("input [%d]: find '%s' expecting paramKeys:(%d)%v , got:(%d)%v", i, tt.r, len(tt.k), tt.k, len(paramKeys), paramKeys)
+		}
+		if !stringSliceEqual(tt.v, paramValues) {
+			t.Errorf("input [%d]: find '%s' expecting paramValues:(%d)%v , got:(%d)%v", i, tt.r, len(tt.v), tt.v, len(paramValues), paramValues)
+		}
+	}
+
+	var patterns []string
+	for _, r := range tr.routes() {
+		patterns = append(patterns, r.Pattern)
+	}
+	if !stringSliceEqual(patterns, []string{"/items/{id:uuid}", "/pages/{n:even}", "/pages/{n:uint}", "/reports/{day:date}.{format:alnum}", "/users/{id:int}", "/users/{name:alpha}"}) {
+		t.Errorf("expecting routes to show the named matchers, got %v", patterns)
+	}
+}
+
+func TestTreeFindRouteFold(t *testing.T) {
+	hStub1 := HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError { return nil })
+	hStub2 := HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError { return nil })
+	hStub3 := HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError { return nil })
+	hStub4 := HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError { return nil })
+
+	tr := &node{}
+	tr.InsertRoute(mGET, "/api/users/{name}", hStub1)
+	tr.InsertRoute(mGET, "/About", hStub2)
+	tr.InsertRoute(mGET, "/about", hStub3)
+	tr.InsertRoute(mGET, "/Admin/Settings/{key}", hStub4)
+
+	tests := []struct {
+		fold   bool     // input case-insensitive search
+		r      string   // input request path
+		h      Handler  // output matched handler
+		v      []string // output param values
+		folded bool     // output whether the path was matched by folding its case
+	}{
+		{fold: false, r: "/api/users/Bob", h: hStub1, v: []string{"Bob"}},
+		{fold: false, r: "/API/Users/Bob", h: nil, v: []string{}},
+		{fold: false, r: "/ABOUT", h: nil, v: []string{}},
+
+		{fold: true, r: "/API/Users/Bob", h: hStub1, v: []string{"Bob"}, folded: true},
+		{fold: true, r: "/api/users/Bob", h: hStub1, v: []string{"Bob"}},
+		{fold: true, r: "/about", h: hStub3, v: []string{}},
+		{fold: true, r: "/About", h: hStub2, v: []string{}},
+		{fold: true, r: "/ABOUT", h: hStub2, v: []string{}, folded: true},
+		{fold: true, r: "/admin/settings/Theme", h: hStub4, v: []string{"Theme"}, folded: true},
+		{fold: true, r: "/admin/nothing", h: nil, v: []string{}},
+	}
+
+	for i, tt := range tests {
+		rctx := NewRouteContext()
+		rctx.caseInsensitive = tt.fold
+
+		_, handlers, _ := tr.FindRoute(rctx, mGET, tt.r)
+
+		var handler Handler
+		if methodHandler, ok := handlers[mGET]; ok {
+			handler = methodHandler.handler
+		}
+
+		if fmt.Sprintf("%v", tt.h) != fmt.Sprintf("%v", handler) {
+			t.Errorf("input [%d]: find '%s' expecting handler:%v , got:%v", i, tt.r, tt.h, handler)
+		}
+		if !stringSliceEqual(tt.v, rctx.routeParams.Values) {
+			t.Errorf("input [%d]: find '%s' expecting paramValues:(%d)%v , got:(%d)%v", i, tt.r, len(tt.v), tt.v, len(rctx.routeParams.Values), rctx.routeParams.Values)
+		}
+		if tt.h != nil && rctx.caseFolded != tt.folded {
+			t.Errorf("input [%d]: find '%s' expecting caseFolded:%v , got:%v", i, tt.r, tt.folded, rctx.caseFolded)
+		}
+	}
+}
+
+func TestRegisterParamMatcher(t *testing.T) {
+	lower := func(s string) bool { return s != "" && strings.ToLower(s) == s }
+	RegisterParamMatcher("alpha", lower)
+	defer func() {
+		paramMatchersMu.Lock()
+		delete(paramMatchers, "alpha")
+		paramMatchersMu.Unlock()
+	}()
+
+	r := NewRouter()
+	r.Named("user").Get("/users/{name:alpha}", func(w http.ResponseWriter, r *http.Request) HandlerError { return nil })
+
+	rctx := NewRouteContext()
+	if !r.Match(rctx, "GET", "/users/bob") || r.Match(rctx, "GET", "/users/Bob") {
+		t.Fatal("expecting the overridden alpha matcher")
+	}
+	if fn, builtin := LookupParamMatcher("alpha"); fn == nil || builtin {
+		t.Fatal("expecting the overridden alpha matcher not to be built-in")
+	}
+	if fn, builtin := LookupParamMatcher("int"); fn == nil || !builtin {
+		t.Fatal("expecting the built-in int matcher")
+	}
+	if fn, _ := LookupParamMatcher("[0-9]+"); fn != nil {
+		t.Fatal("expecting no matcher for a regexp")
+	}
+
+	// Matchers may be registered while URLs are built
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			RegisterParamMatcher(fmt.Sprintf("concurrent%d", i), lower)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if _, err := r.URLFor("user", "name", "bob"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+}
+
 func TestTreeFindPattern(t *testing.T) {
 	hStub1 := HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError { return nil })
 	hStub2 := HandlerFunc(func(w http.ResponseWriter, r *http.Request) HandlerError { return nil })
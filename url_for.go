@@ -0,0 +1,176 @@
+package chi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// URLFor returns the URL path of the route named `name`, looked up from the
+// router serving the request. See Mux.URLFor.
+func URLFor(r *http.Request, name string, params ...string) (string, error) {
+	rctx := RouteContext(r.Context())
+	if rctx == nil {
+		return "", errors.New("chi: URLFor called without a routing context")
+	}
+	u, ok := rctx.Routes.(interface {
+		URLFor(name string, params ...string) (string, error)
+	})
+	if !ok {
+		return "", errors.New("chi: URLFor called with a router that has no named routes")
+	}
+	return u.URLFor(name, params...)
+}
+
+// URLFor returns the URL path of the route named `name`, see Named. The route
+// is looked up in the Mux and its mounted sub-routers, whose mount patterns
+// are prepended to the path.
+//
+// `params` are key/value pairs of the URL params of the routing patterns.
+// Their values are escaped and must satisfy the regexps and matchers of the
+// params. The value of the wildcard key "*" may contain slashes and defaults
+// to an empty string. URLFor returns an error for a missing or unknown param.
+//
+// A name added to a sub-router after it was attached isn't checked against
+// the names of its parents. The route is then looked up in the Mux first,
+// followed by its sub-routers in the order of their patterns and the
+// sub-routers added by Host.
+//
+// The URL of a route of a sub-router added by Host is a scheme-relative URL,
+// such as "//acme.example.com/users", with the host params substituted
+// like the params of the path.
+func (mx *Mux) URLFor(name string, params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("chi: odd number of URL params for route '%s'", name)
+	}
+
+	m := mx
+	for m.inline && m.parent != nil {
+		m = m.parent
+	}
+	patterns, ok := m.namedRoute(name)
+	if !ok {
+		return "", fmt.Errorf("chi: unknown route name '%s'", name)
+	}
+	pattern := replaceWildcards(strings.Join(patterns, ""))
+
+	var b strings.Builder
+	used := 0
+	for {
+		typ, key, rexpat, _, start, end := patNextSegment(pattern)
+		if typ == ntStatic {
+			b.WriteString(pattern)
+			break
+		}
+		b.WriteString(pattern[:start])
+		pattern = pattern[end:]
+
+		value, ok := urlForParam(params, key)
+		if ok {
+			used++
+		}
+
+		if typ == ntCatchAll {
+			segments := strings.Split(value, "/")
+			for i, s := range segments {
+				segments[i] = url.PathEscape(s)
+			}
+			b.WriteString(strings.Join(segments, "/"))
+			continue
+		}
+
+		if !ok {
+			return "", fmt.Errorf("chi: missing URL param '%s' for route '%s'", key, name)
+		}
+		if typ == ntRegexp && !matchParam(rexpat, value) {
+			return "", fmt.Errorf("chi: URL param '%s' for route '%s' doesn't match '%s'", key, name, rexpat)
+		}
+		b.WriteString(url.PathEscape(value))
+	}
+
+	if used != len(params)/2 {
+		return "", fmt.Errorf("chi: unknown URL params for route '%s'", name)
+	}
+	return b.String(), nil
+}
+
+// namedRoute returns the routing patterns leading to the route `name`, from
+// the mount patterns of sub-routers down to the pattern of the route.
+func (mx *Mux) namedRoute(name string) ([]string, bool) {
+	if pattern, ok := mx.routeNames[name]; ok {
+		return []string{pattern}, true
+	}
+	for _, r := range mx.tree.routes() {
+		subMux, ok := r.SubRoutes.(*Mux)
+		if !ok {
+			continue
+		}
+		if patterns, ok := subMux.namedRoute(name); ok {
+			return append([]string{r.Pattern}, patterns...), true
+		}
+	}
+	if mx.hosts != nil {
+		for _, r := range mx.hosts.routes() {
+			if patterns, ok := r.SubRoutes.(*Mux).namedRoute(name); ok {
+				return append([]string{"//" + r.Pattern}, patterns...), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// routeNameList returns the route names of the Mux and its sub-routers.
+func (mx *Mux) routeNameList() []string {
+	var names []string
+	for name := range mx.routeNames {
+		names = append(names, name)
+	}
+	for _, r := range mx.tree.routes() {
+		if subMux, ok := r.SubRoutes.(*Mux); ok {
+			names = append(names, subMux.routeNameList()...)
+		}
+	}
+	if mx.hosts != nil {
+		for _, r := range mx.hosts.routes() {
+			names = append(names, r.SubRoutes.(*Mux).routeNameList()...)
+		}
+	}
+	return names
+}
+
+// checkRouteNames panics if a route name of the sub-router `subr`, attached
+// on `pattern`, is already in use by the routes of the Mux.
+func (mx *Mux) checkRouteNames(pattern string, subr *Mux) {
+	m := mx
+	for m.inline && m.parent != nil {
+		m = m.parent
+	}
+	for _, name := range subr.routeNameList() {
+		if patterns, ok := m.namedRoute(name); ok {
+			panic(fmt.Sprintf("chi: route name '%s' of the sub-router on '%s' is already in use for '%s'",
+				name, pattern, replaceWildcards(strings.Join(patterns, ""))))
+		}
+	}
+}
+
+// urlForParam returns the value of the URL param `key` in `params`.
+func urlForParam(params []string, key string) (string, bool) {
+	for i := 0; i < len(params); i += 2 {
+		if params[i] == key {
+			return params[i+1], true
+		}
+	}
+	return "", false
+}
+
+// matchParam reports whether `value` satisfies the regexp or named matcher
+// `rexpat` of a URL param.
+func matchParam(rexpat, value string) bool {
+	if matcher, ok := paramMatcher(rexpat); ok {
+		return matcher(value)
+	}
+	rex, err := compileParamRegexp(rexpat)
+	return err == nil && rex.MatchString(value)
+}
@@ -0,0 +1,136 @@
+package chi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMuxURLFor(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) HandlerError { return nil }
+
+	r := NewRouter()
+	r.Named("home").Get("/", h)
+	r.Named("article").Get("/articles/{articleID:int}", h)
+	r.Named("file").Get("/files/*", h)
+	r.Route("/{tenant}/admin", func(r Router) {
+		r.Named("user").Get("/users/{name}", h)
+		r.Group(func(r Router) {
+			r.Named("settings").Put("/settings/{key:[a-z]+}", h)
+		})
+	})
+	r.Named("static").Mount("/static", HandlerFunc(h))
+	articles := r.Named("articles")
+	articles.Get("/articles", h)
+	articles.Post("/articles", h)
+
+	tests := []struct {
+		name   string
+		params []string
+		url    string
+		err    string
+	}{
+		{"home", nil, "/", ""},
+		{"article", []string{"articleID", "42"}, "/articles/42", ""},
+		{"file", []string{"*", "css/app v2.css"}, "/files/css/app%20v2.css", ""},
+		{"file", nil, "/files/", ""},
+		{"user", []string{"tenant", "acme", "name", "jane/doe"}, "/acme/admin/users/jane%2Fdoe", ""},
+		{"settings", []string{"key", "theme", "tenant", "acme"}, "/acme/admin/settings/theme", ""},
+		{"static", []string{"*", "img/logo.png"}, "/static/img/logo.png", ""},
+		{"articles", nil, "/articles", ""},
+
+		{"nothing", nil, "", "chi: unknown route name 'nothing'"},
+		{"article", nil, "", "chi: missing URL param 'articleID' for route 'article'"},
+		{"article", []string{"articleID"}, "", "chi: odd number of URL params for route 'article'"},
+		{"article", []string{"articleID", "abc"}, "", "chi: URL param 'articleID' for route 'article' doesn't match 'int'"},
+		{"settings", []string{"key", "Theme", "tenant", "acme"}, "", "chi: URL param 'key' for route 'settings' doesn't match '^[a-z]+$'"},
+		{"article", []string{"articleID", "42", "page", "2"}, "", "chi: unknown URL params for route 'article'"},
+	}
+
+	for _, tt := range tests {
+		url, err := r.URLFor(tt.name, tt.params...)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("%s %v: expecting error '%s', got '%v'", tt.name, tt.params, tt.err, err)
+			}
+			continue
+		}
+		if err != nil || url != tt.url {
+			t.Fatalf("%s %v: expecting '%s', got '%s' (%v)", tt.name, tt.params, tt.url, url, err)
+		}
+	}
+
+	if url, err := r.With().(*Mux).URLFor("home"); err != nil || url != "/" {
+		t.Fatalf("expecting an inline router to build '/', got '%s' (%v)", url, err)
+	}
+}
+
+func TestURLForRequest(t *testing.T) {
+	r := NewRouter()
+	r.Route("/api", func(r Router) {
+		r.Named("article").Get("/articles/{id}", func(w http.ResponseWriter, r *http.Request) HandlerError {
+			url, err := URLFor(r, "article", "id", "next")
+			if err != nil {
+				return Error{Code: 500, Err: err}
+			}
+			w.Write([]byte(url))
+			return nil
+		})
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	if _, body := testRequest(t, ts, "GET", "/api/articles/1", nil); body != "/api/articles/next" {
+		t.Fatalf("expecting '/api/articles/next', got '%s'", body)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expecting panic for a duplicate route name")
+		}
+	}()
+	r.Named("article").Get("/article", func(w http.ResponseWriter, r *http.Request) HandlerError { return nil })
+	r.Named("article").Get("/article2", func(w http.ResponseWriter, r *http.Request) HandlerError { return nil })
+}
+
+func TestMuxURLForDuplicateNames(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) HandlerError { return nil }
+
+	assertPanic := func(msg string, fn func()) {
+		t.Helper()
+		defer func() {
+			if rvr := recover(); rvr != msg {
+				t.Fatalf("expecting panic '%s', got '%v'", msg, rvr)
+			}
+		}()
+		fn()
+	}
+
+	r := NewRouter()
+	r.Named("user").Get("/users/{id}", h)
+	assertPanic("chi: route name 'user' of the sub-router on '/admin' is already in use for '/users/{id}'", func() {
+		r.Route("/admin", func(r Router) {
+			r.Named("user").Get("/users/{id}", h)
+		})
+	})
+
+	r = NewRouter()
+	r.Route("/admin", func(r Router) {
+		r.Named("user").Get("/users/{id}", h)
+	})
+	assertPanic("chi: route name 'user' is already in use for '/admin/users/{id}'", func() {
+		r.Named("user").Get("/users/{id}", h)
+	})
+
+	// A name added to a sub-router after it was mounted is looked up in its
+	// parent first
+	sub := NewRouter()
+	r = NewRouter()
+	r.Mount("/sub", sub)
+	r.Named("page").Get("/pages/{id}", h)
+	sub.Named("page").Get("/pages/{id}", h)
+	if url, err := r.URLFor("page", "id", "1"); err != nil || url != "/pages/1" {
+		t.Fatalf("expecting the route of the parent, got '%s' (%v)", url, err)
+	}
+}
@@ -0,0 +1,139 @@
+package chi
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	errMissingURLParam = errors.New("missing value")
+	errInvalidUUID     = errors.New("invalid UUID")
+)
+
+// URLParamError is the error returned by the typed URL param accessors of
+// Context for a missing or malformed value. It results in a 400 Bad Request.
+type URLParamError struct {
+	// Key is the name of the URL param.
+	Key string
+
+	// Value is the raw value of the URL param.
+	Value string
+
+	// Err is the error returned by parsing the value.
+	Err error
+}
+
+// StatusCode returns http.StatusBadRequest.
+func (e URLParamError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+func (e URLParamError) Error() string {
+	return fmt.Sprintf("invalid URL param %q: %v", e.Key, e.Err)
+}
+
+func (e URLParamError) Unwrap() error {
+	return e.Err
+}
+
+// URLParamFunc parses the URL param `key` with `parse`. An error returned by
+// `parse` is wrapped in a URLParamError, for example:
+//
+//   var date civil.Date
+//   err := rctx.URLParamFunc("date", func(s string) (err error) {
+//     date, err = civil.ParseDate(s)
+//     return err
+//   })
+//   if err != nil {
+//     return err
+//   }
+func (x *Context) URLParamFunc(key string, parse func(value string) error) HandlerError {
+	value := x.URLParam(key)
+	if value == "" {
+		return URLParamError{Key: key, Err: errMissingURLParam}
+	}
+	if err := parse(value); err != nil {
+		var numErr *strconv.NumError
+		if errors.As(err, &numErr) {
+			// Leave out the name of the strconv function from the message
+			err = numErr.Err
+		}
+		return URLParamError{Key: key, Value: value, Err: err}
+	}
+	return nil
+}
+
+// URLParamInt returns the URL param `key` as an int.
+func (x *Context) URLParamInt(key string) (int, HandlerError) {
+	var v int
+	err := x.URLParamFunc(key, func(s string) (err error) {
+		v, err = strconv.Atoi(s)
+		return err
+	})
+	return v, err
+}
+
+// URLParamInt64 returns the URL param `key` as an int64.
+func (x *Context) URLParamInt64(key string) (int64, HandlerError) {
+	var v int64
+	err := x.URLParamFunc(key, func(s string) (err error) {
+		v, err = strconv.ParseInt(s, 10, 64)
+		return err
+	})
+	return v, err
+}
+
+// URLParamBool returns the URL param `key` as a bool, accepting the values
+// of strconv.ParseBool.
+func (x *Context) URLParamBool(key string) (bool, HandlerError) {
+	var v bool
+	err := x.URLParamFunc(key, func(s string) (err error) {
+		v, err = strconv.ParseBool(s)
+		return err
+	})
+	return v, err
+}
+
+// URLParamTime returns the URL param `key` as a time.Time in the format
+// `layout`, see time.Parse.
+func (x *Context) URLParamTime(key, layout string) (time.Time, HandlerError) {
+	var v time.Time
+	err := x.URLParamFunc(key, func(s string) (err error) {
+		v, err = time.Parse(layout, s)
+		return err
+	})
+	return v, err
+}
+
+// URLParamUUID returns the URL param `key` as the bytes of a UUID in its
+// canonical form, such as "f47ac10b-58cc-4372-a567-0e02b2c3d479". The
+// result converts to the UUID types of common UUID packages.
+func (x *Context) URLParamUUID(key string) ([16]byte, HandlerError) {
+	var v [16]byte
+	err := x.URLParamFunc(key, func(s string) (err error) {
+		v, err = parseUUID(s)
+		return err
+	})
+	return v, err
+}
+
+// parseUUID parses a UUID in its canonical, hyphenated form.
+func parseUUID(s string) ([16]byte, error) {
+	var uuid [16]byte
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return uuid, errInvalidUUID
+	}
+	b := 0
+	for _, group := range [5][2]int{{0, 8}, {9, 13}, {14, 18}, {19, 23}, {24, 36}} {
+		n, err := hex.Decode(uuid[b:], []byte(s[group[0]:group[1]]))
+		if err != nil {
+			return uuid, errInvalidUUID
+		}
+		b += n
+	}
+	return uuid, nil
+}
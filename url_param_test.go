@@ -0,0 +1,95 @@
+package chi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestURLParamAccessors(t *testing.T) {
+	r := NewRouter()
+	r.Get("/users/{id}/active/{active}", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		rctx := RouteContext(r.Context())
+		id, err := rctx.URLParamInt64("id")
+		if err != nil {
+			return err
+		}
+		active, err := rctx.URLParamBool("active")
+		if err != nil {
+			return err
+		}
+		w.Write([]byte(strconv.FormatInt(id, 10) + " " + strconv.FormatBool(active)))
+		return nil
+	})
+	r.Get("/reports/{date}", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		date, err := RouteContext(r.Context()).URLParamTime("date", "2006-01-02")
+		if err != nil {
+			return err
+		}
+		w.Write([]byte(date.Format("Jan 2 2006")))
+		return nil
+	})
+	r.Get("/items/{uuid}", func(w http.ResponseWriter, r *http.Request) HandlerError {
+		uuid, err := RouteContext(r.Context()).URLParamUUID("uuid")
+		if err != nil {
+			return err
+		}
+		w.Write(uuid[:2])
+		return nil
+	})
+
+	ts := httptest.NewServer(r.ToHTTPHandler())
+	defer ts.Close()
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/users/42/active/true", 200, "42 true"},
+		{"/users/abc/active/true", 400, "invalid URL param \"id\": invalid syntax\n"},
+		{"/users/99999999999999999999/active/true", 400, "invalid URL param \"id\": value out of range\n"},
+		{"/users/42/active/maybe", 400, "invalid URL param \"active\": invalid syntax\n"},
+		{"/reports/2020-02-29", 200, "Feb 29 2020"},
+		{"/items/4142c10b-58cc-4372-a567-0e02b2c3d479", 200, "AB"},
+		{"/items/4142c10b58cc4372a5670e02b2c3d479", 400, "invalid URL param \"uuid\": invalid UUID\n"},
+		{"/items/zz42c10b-58cc-4372-a567-0e02b2c3d479", 400, "invalid URL param \"uuid\": invalid UUID\n"},
+	}
+	for _, tt := range tests {
+		if resp, body := testRequest(t, ts, "GET", tt.path, nil); resp.StatusCode != tt.status || body != tt.body {
+			t.Fatalf("%s: expecting %d with '%s', got %d with '%s'", tt.path, tt.status, tt.body, resp.StatusCode, body)
+		}
+	}
+}
+
+func TestURLParamFunc(t *testing.T) {
+	errOdd := errors.New("odd number")
+
+	rctx := NewRouteContext()
+	rctx.URLParams.Add("n", "3")
+
+	err := rctx.URLParamFunc("n", func(s string) error {
+		n, err := strconv.Atoi(s)
+		if err == nil && n%2 != 0 {
+			return errOdd
+		}
+		return err
+	})
+	if err == nil || err.StatusCode() != http.StatusBadRequest || !errors.Is(err, errOdd) {
+		t.Fatalf("expecting a 400 wrapping the parse error, got %v", err)
+	}
+	if perr, ok := err.(URLParamError); !ok || perr.Key != "n" || perr.Value != "3" {
+		t.Fatalf("expecting URLParamError for key 'n', got %#v", err)
+	}
+
+	if _, err := rctx.URLParamInt("missing"); err == nil || err.Error() != "invalid URL param \"missing\": missing value" {
+		t.Fatalf("expecting missing value error, got %v", err)
+	}
+
+	if _, err := rctx.URLParamTime("n", time.RFC3339); err == nil {
+		t.Fatal("expecting error for malformed time")
+	}
+}